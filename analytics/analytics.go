@@ -0,0 +1,342 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package analytics 一个简单的访问统计功能。
+//
+// 只统计文章的浏览量、访客数量和来源网站，不使用 cookie，也不保存 IP：
+// 访客以 IP 和 User-Agent 加上每天随机生成的盐值的哈希值进行区分，
+// 盐值只保存在内存中，第二天即被替换，所以无法通过哈希值反推访客信息，
+// 也无法关联同一访客在不同日期的访问。
+package analytics
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/issue9/utils"
+)
+
+// 最多保存的来源网站数量，超出时只保留访问量最多的一半。
+const maxReferrers = 1000
+
+// 用于识别爬虫等非真实用户的 User-Agent
+var botExpr = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|archiver|facebookexternalhit|preview|monitor|lighthouse|headless|curl|wget|python|java/|go-http-client|okhttp|feed|rss`)
+
+// Analytics 访问统计
+type Analytics struct {
+	file   string
+	locker sync.Mutex
+	stats  *stats
+	dirty  bool // 是否有未保存的数据
+
+	// 按浏览量排序的文章 slug，只在加载和保存数据时更新，
+	// 防止每次输出页面时都需要重新排序。
+	popular []string
+	version int // popular 的版本号，每次更新 popular 都会改变
+
+	day  string          // 当前盐值所对应的日期
+	salt []byte          // 当日的盐值
+	seen map[string]bool // 当日已经访问过的访客和文章
+}
+
+// 需要保存到文件中的统计数据
+type stats struct {
+	Since     time.Time             `json:"since"` // 开始统计的时间
+	Posts     map[string]*postStats `json:"posts"`
+	Referrers map[string]int        `json:"referrers"` // 来源网站的域名及其数量
+}
+
+type postStats struct {
+	Views    int `json:"views"`    // 浏览量
+	Visitors int `json:"visitors"` // 访客数量，同一访客在同一天只计算一次
+}
+
+// Count 表示一项统计结果
+type Count struct {
+	Name     string // 文章的 slug 或是来源网站的域名
+	Views    int
+	Visitors int // 仅文章有此值
+}
+
+// New 声明一个新的 Analytics 实例，file 为保存统计数据的文件，
+// 若文件已经存在，则从中加载之前的数据。
+func New(file string) (*Analytics, error) {
+	a := &Analytics{
+		file: file,
+		stats: &stats{
+			Since:     time.Now(),
+			Posts:     make(map[string]*postStats, 100),
+			Referrers: make(map[string]int, 100),
+		},
+	}
+
+	if !utils.FileExists(file) {
+		return a, nil
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(content, a.stats); err != nil {
+		return nil, err
+	}
+
+	// 旧文件中可能缺少某些字段
+	if a.stats.Posts == nil {
+		a.stats.Posts = make(map[string]*postStats, 100)
+	}
+	if a.stats.Referrers == nil {
+		a.stats.Referrers = make(map[string]int, 100)
+	}
+
+	a.refresh()
+
+	return a, nil
+}
+
+// IsBot 是否为爬虫等非真实用户的访问
+func IsBot(r *http.Request) bool {
+	ua := r.UserAgent()
+	return len(ua) == 0 || botExpr.MatchString(ua)
+}
+
+// Hit 记录一次对文章 slug 的访问，爬虫和非 GET 请求会被忽略。
+func (a *Analytics) Hit(r *http.Request, slug string) {
+	if r.Method != http.MethodGet || IsBot(r) {
+		return
+	}
+
+	a.locker.Lock()
+	defer a.locker.Unlock()
+
+	a.rotateSalt(time.Now())
+
+	ps, found := a.stats.Posts[slug]
+	if !found {
+		ps = &postStats{}
+		a.stats.Posts[slug] = ps
+	}
+	ps.Views++
+
+	visitor := a.visitor(r, slug)
+	if !a.seen[visitor] {
+		a.seen[visitor] = true
+		ps.Visitors++
+	}
+
+	if host := referrer(r); len(host) > 0 {
+		if _, found := a.stats.Referrers[host]; !found && len(a.stats.Referrers) >= maxReferrers {
+			a.pruneReferrers()
+		}
+		a.stats.Referrers[host]++
+	}
+
+	a.dirty = true
+}
+
+// 只保留访问量最多的 maxReferrers/2 个来源网站，防止来源网站无限增长。
+func (a *Analytics) pruneReferrers() {
+	counts := make([]*Count, 0, len(a.stats.Referrers))
+	for host, views := range a.stats.Referrers {
+		counts = append(counts, &Count{Name: host, Views: views})
+	}
+
+	referrers := make(map[string]int, maxReferrers)
+	for _, c := range top(counts, maxReferrers/2) {
+		referrers[c.Name] = c.Views
+	}
+	a.stats.Referrers = referrers
+}
+
+// 每天更换一次盐值，同时清空当日的访客记录。
+func (a *Analytics) rotateSalt(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	if day == a.day {
+		return
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+
+	a.day = day
+	a.salt = salt
+	a.seen = make(map[string]bool, 100)
+}
+
+// 根据 IP、User-Agent 和盐值计算访客的标记
+func (a *Analytics) visitor(r *http.Request, slug string) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	h := sha256.New()
+	h.Write(a.salt)
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(r.UserAgent()))
+	h.Write([]byte{0})
+	h.Write([]byte(slug))
+	return string(h.Sum(nil))
+}
+
+// 获取来源网站的域名，站内跳转和无法解析的返回空值。
+func referrer(r *http.Request) string {
+	ref := r.Referer()
+	if len(ref) == 0 {
+		return ""
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || len(u.Host) == 0 || u.Host == r.Host {
+		return ""
+	}
+
+	return u.Hostname()
+}
+
+// Since 开始统计的时间
+func (a *Analytics) Since() time.Time {
+	a.locker.Lock()
+	defer a.locker.Unlock()
+	return a.stats.Since
+}
+
+// Popular 返回浏览量最多的 size 篇文章的 slug，size 小于等于 0 表示返回全部。
+//
+// 结果只在加载和保存数据时更新，所以与 TopPosts 相比，可能会有 interval 的延迟。
+func (a *Analytics) Popular(size int) []string {
+	a.locker.Lock()
+	defer a.locker.Unlock()
+
+	if size > 0 && len(a.popular) > size {
+		return a.popular[:size]
+	}
+	return a.popular
+}
+
+// Version 返回 Popular 结果的版本号，版本号未变化时，Popular 的结果也不会变化。
+func (a *Analytics) Version() int {
+	a.locker.Lock()
+	defer a.locker.Unlock()
+	return a.version
+}
+
+// 更新 popular 的内容，调用方需要负责加锁。
+func (a *Analytics) refresh() {
+	counts := make([]*Count, 0, len(a.stats.Posts))
+	for slug, ps := range a.stats.Posts {
+		counts = append(counts, &Count{Name: slug, Views: ps.Views, Visitors: ps.Visitors})
+	}
+
+	popular := make([]string, 0, len(counts))
+	for _, c := range top(counts, 0) {
+		popular = append(popular, c.Name)
+	}
+
+	a.popular = popular
+	a.version++
+}
+
+// TopPosts 返回浏览量最多的 size 篇文章，size 小于等于 0 表示返回全部。
+func (a *Analytics) TopPosts(size int) []*Count {
+	a.locker.Lock()
+	counts := make([]*Count, 0, len(a.stats.Posts))
+	for slug, ps := range a.stats.Posts {
+		counts = append(counts, &Count{Name: slug, Views: ps.Views, Visitors: ps.Visitors})
+	}
+	a.locker.Unlock()
+
+	return top(counts, size)
+}
+
+// TopReferrers 返回访问量最多的 size 个来源网站，size 小于等于 0 表示返回全部。
+func (a *Analytics) TopReferrers(size int) []*Count {
+	a.locker.Lock()
+	counts := make([]*Count, 0, len(a.stats.Referrers))
+	for host, views := range a.stats.Referrers {
+		counts = append(counts, &Count{Name: host, Views: views})
+	}
+	a.locker.Unlock()
+
+	return top(counts, size)
+}
+
+// 按浏览量从高到低排序，浏览量相同的按名称排序，并截取前 size 项。
+func top(counts []*Count, size int) []*Count {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Views != counts[j].Views {
+			return counts[i].Views > counts[j].Views
+		}
+		return counts[i].Name < counts[j].Name
+	})
+
+	if size > 0 && len(counts) > size {
+		counts = counts[:size]
+	}
+	return counts
+}
+
+// Save 将统计数据保存到文件，没有新数据时不作任何操作。
+//
+// 先写入临时文件再重命名，防止写入过程中程序中断导致数据丢失。
+func (a *Analytics) Save() error {
+	a.locker.Lock()
+	if !a.dirty {
+		a.locker.Unlock()
+		return nil
+	}
+	a.refresh()
+	content, err := json.Marshal(a.stats)
+	a.dirty = false
+	a.locker.Unlock()
+
+	if err == nil {
+		err = a.write(content)
+	}
+
+	if err != nil { // 保存失败，下次继续尝试
+		a.locker.Lock()
+		a.dirty = true
+		a.locker.Unlock()
+	}
+	return err
+}
+
+func (a *Analytics) write(content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(a.file), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp := a.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.file)
+}
+
+// Run 每隔 interval 保存一次数据，errlog 用于输出保存时的错误信息。
+func (a *Analytics) Run(interval time.Duration, errlog func(error)) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := a.Save(); err != nil {
+				errlog(err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert"
+)
+
+func newRequest(ip, ua, referer string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/posts/p1.html", nil)
+	r.RemoteAddr = ip + ":1234"
+	r.Header.Set("User-Agent", ua)
+	if len(referer) > 0 {
+		r.Header.Set("Referer", referer)
+	}
+	return r
+}
+
+func TestIsBot(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(IsBot(newRequest("1.1.1.1", "", "")))
+	a.True(IsBot(newRequest("1.1.1.1", "Mozilla/5.0 (compatible; Googlebot/2.1)", "")))
+	a.True(IsBot(newRequest("1.1.1.1", "curl/7.54.0", "")))
+	a.False(IsBot(newRequest("1.1.1.1", "Mozilla/5.0 (X11; Linux x86_64) Firefox/57.0", "")))
+}
+
+func TestAnalytics_Hit(t *testing.T) {
+	a := assert.New(t)
+	an, err := New("./not-exists.json")
+	a.NotError(err).NotNil(an)
+
+	ua := "Mozilla/5.0 Firefox/57.0"
+	an.Hit(newRequest("1.1.1.1", ua, "https://example.org/abc"), "p1")
+	an.Hit(newRequest("1.1.1.1", ua, "http://example.org/def"), "p1") // 同一访客
+	an.Hit(newRequest("2.2.2.2", ua, "http://example.org/p1.html"), "p1")
+	an.Hit(newRequest("2.2.2.2", ua, "https://google.com/"), "p2")
+	an.Hit(newRequest("3.3.3.3", "Googlebot", "https://google.com/"), "p2") // 爬虫
+	an.Hit(newRequest("3.3.3.3", ua, "https://example.com/"), "p3")         // 站内跳转
+
+	posts := an.TopPosts(2)
+	a.Equal(len(posts), 2)
+	a.Equal(posts[0], &Count{Name: "p1", Views: 3, Visitors: 2})
+	a.Equal(posts[1], &Count{Name: "p2", Views: 1, Visitors: 1})
+	a.Empty(an.Popular(0)) // 未保存之前不会更新
+
+	v := an.Version()
+	an.locker.Lock()
+	an.refresh()
+	an.locker.Unlock()
+	a.Equal(an.Popular(0), []string{"p1", "p2", "p3"})
+	a.Equal(an.Popular(2), []string{"p1", "p2"})
+	a.NotEqual(an.Version(), v)
+
+	refs := an.TopReferrers(0)
+	a.Equal(len(refs), 2)
+	a.Equal(refs[0], &Count{Name: "example.org", Views: 3})
+	a.Equal(refs[1], &Count{Name: "google.com", Views: 1})
+
+	// 更换盐值之后，同一访客重新计算
+	an.rotateSalt(time.Now().Add(48 * time.Hour))
+	an.Hit(newRequest("1.1.1.1", ua, ""), "p1")
+	a.Equal(an.TopPosts(1)[0], &Count{Name: "p1", Views: 4, Visitors: 3})
+}
+
+func TestAnalytics_Save(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "gitype-analytics")
+	a.NotError(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "stats", "analytics.json")
+
+	an, err := New(file)
+	a.NotError(err)
+	a.NotError(an.Save()) // 没有数据，不会创建文件
+	_, err = os.Stat(file)
+	a.True(os.IsNotExist(err))
+
+	an.Hit(newRequest("1.1.1.1", "Mozilla/5.0", "https://google.com/"), "p1")
+	a.NotError(an.Save())
+
+	content, err := ioutil.ReadFile(file)
+	a.NotError(err)
+	a.False(strings.Contains(string(content), "1.1.1.1")) // 不保存 IP
+
+	an, err = New(file)
+	a.NotError(err)
+	a.Equal(an.TopPosts(0), []*Count{{Name: "p1", Views: 1, Visitors: 1}})
+	a.Equal(an.TopReferrers(0), []*Count{{Name: "google.com", Views: 1}})
+	a.Equal(an.Popular(0), []string{"p1"}) // 加载时更新
+}
+
+func TestAnalytics_pruneReferrers(t *testing.T) {
+	a := assert.New(t)
+	an, err := New("./not-exists.json")
+	a.NotError(err)
+
+	ua := "Mozilla/5.0 Firefox/57.0"
+	an.Hit(newRequest("1.1.1.1", ua, "https://example.org/"), "p1")
+	an.Hit(newRequest("2.2.2.2", ua, "https://example.org/"), "p1")
+	for i := 0; i < maxReferrers; i++ {
+		an.Hit(newRequest("1.1.1.1", ua, "https://"+strconv.Itoa(i)+".example.com/"), "p1")
+	}
+
+	refs := an.TopReferrers(0)
+	a.Equal(len(refs), maxReferrers/2+1) // 清理之后又添加了一项
+	a.Equal(refs[0], &Count{Name: "example.org", Views: 2})
+}
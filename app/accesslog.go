@@ -0,0 +1,286 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/issue9/logs"
+	"github.com/issue9/utils"
+)
+
+// 访问日志的格式
+const (
+	accessLogCommon   = "common"   // Common Log Format
+	accessLogCombined = "combined" // Combined Log Format，即在 common 的基础上加上 referer 和 user-agent
+	accessLogJSON     = "json"     // 每行一个 JSON 对象
+)
+
+// 访问日志中的时间格式，与 Apache 和 Nginx 的相同。
+const accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// 轮转之后的文件名后缀格式
+const accessLogRotateFormat = "20060102150405"
+
+type accessLog struct {
+	Path       string `yaml:"path"`                 // 日志文件的路径，相对路径表示相对于程序的工作目录
+	Format     string `yaml:"format,omitempty"`     // 日志格式，可以是 common、combined 和 json，默认为 combined
+	MaxSize    int64  `yaml:"maxSize,omitempty"`    // 单个文件的最大字节数，超过时进行轮转，0 表示不轮转
+	MaxBackups int    `yaml:"maxBackups,omitempty"` // 保留的旧文件数量，0 表示全部保留
+}
+
+// 一条访问记录
+type accessEntry struct {
+	Time      time.Time `json:"time"`
+	IP        string    `json:"ip"`
+	User      string    `json:"user,omitempty"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Bytes     int64     `json:"bytes"`
+	Duration  float64   `json:"duration"` // 处理请求的耗时，单位为秒
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+}
+
+// 记录状态码和输出字节数的 http.ResponseWriter
+type accessWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (l *accessLog) sanitize() *helper.FieldError {
+	if len(l.Format) == 0 {
+		l.Format = accessLogCombined
+	}
+
+	switch {
+	case len(l.Path) == 0:
+		return &helper.FieldError{Field: "accessLog.path", Message: "不能为空"}
+	case l.Format != accessLogCommon && l.Format != accessLogCombined && l.Format != accessLogJSON:
+		return &helper.FieldError{Field: "accessLog.format", Message: "无效的取值"}
+	case l.MaxSize < 0:
+		return &helper.FieldError{Field: "accessLog.maxSize", Message: "不能小于 0"}
+	case l.MaxBackups < 0:
+		return &helper.FieldError{Field: "accessLog.maxBackups", Message: "不能小于 0"}
+	}
+
+	return nil
+}
+
+func (w *accessWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessWriter) Write(bs []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(bs)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// 输出访问日志，未配置 accessLog 时，直接返回 h。
+func (a *app) buildAccessLog(h http.Handler) (http.Handler, error) {
+	conf := a.conf.AccessLog
+	if conf == nil {
+		return h, nil
+	}
+
+	file := conf.Path
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(a.path.Root, file)
+	}
+	out, err := newRotateWriter(file, conf.MaxSize, conf.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	return accessLogHandler(h, out, conf.Format), nil
+}
+
+func accessLogHandler(h http.Handler, out io.Writer, format string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		aw := &accessWriter{ResponseWriter: w}
+		h.ServeHTTP(aw, r)
+
+		if aw.status == 0 {
+			aw.status = http.StatusOK
+		}
+
+		user, _, _ := r.BasicAuth()
+
+		e := &accessEntry{
+			Time:      start,
+			IP:        remoteIP(r),
+			User:      user,
+			Method:    r.Method,
+			URI:       r.RequestURI,
+			Proto:     r.Proto,
+			Status:    aw.status,
+			Bytes:     aw.bytes,
+			Duration:  time.Since(start).Seconds(),
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+		}
+
+		if _, err := out.Write(e.format(format)); err != nil {
+			logs.Error(err)
+		}
+	})
+}
+
+// 将访问记录转换成指定格式的一行内容，包含换行符。
+func (e *accessEntry) format(format string) []byte {
+	if format == accessLogJSON {
+		bs, err := json.Marshal(e)
+		if err != nil { // 所有字段都是简单类型，不应该出错
+			panic(err)
+		}
+		return append(bs, '\n')
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(e.IP)
+	buf.WriteString(" - ")
+	buf.WriteString(clfValue(e.User))
+	buf.WriteString(" [")
+	buf.WriteString(e.Time.Format(accessLogTimeFormat))
+	buf.WriteString(`] "`)
+	buf.WriteString(e.Method + " " + e.URI + " " + e.Proto)
+	buf.WriteString(`" `)
+	buf.WriteString(strconv.Itoa(e.Status))
+	buf.WriteByte(' ')
+	if e.Bytes == 0 {
+		buf.WriteByte('-')
+	} else {
+		buf.WriteString(strconv.FormatInt(e.Bytes, 10))
+	}
+
+	if format == accessLogCombined {
+		buf.WriteString(` "` + clfValue(e.Referer) + `" "` + clfValue(e.UserAgent) + `"`)
+	}
+
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// 将值转换成 CLF 中可用的格式，空值用 - 表示，去掉引号，防止破坏日志的格式。
+func clfValue(v string) string {
+	if len(v) == 0 {
+		return "-"
+	}
+
+	v = strconv.Quote(v)
+	return v[1 : len(v)-1]
+}
+
+// 按文件大小轮转的日志文件
+type rotateWriter struct {
+	locker     sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func newRotateWriter(path string, maxSize int64, maxBackups int) (*rotateWriter, error) {
+	w := &rotateWriter{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = stat.Size()
+	return nil
+}
+
+func (w *rotateWriter) Write(bs []byte) (int, error) {
+	w.locker.Lock()
+	defer w.locker.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(bs)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(bs)
+	w.size += int64(n)
+	return n, err
+}
+
+// 将当前文件重命名为带时间后缀的文件，并删除多余的旧文件。
+func (w *rotateWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	backup := w.path + "." + time.Now().Format(accessLogRotateFormat)
+	for i := 1; utils.FileExists(backup); i++ { // 同一秒内多次轮转
+		backup = w.path + "." + time.Now().Format(accessLogRotateFormat) + "-" + strconv.Itoa(i)
+	}
+	if err := os.Rename(w.path, backup); err != nil {
+		return err
+	}
+
+	if w.maxBackups > 0 {
+		backups, err := filepath.Glob(w.path + ".*")
+		if err != nil {
+			return err
+		}
+		sort.Strings(backups)
+		for len(backups) > w.maxBackups {
+			if err := os.Remove(backups[0]); err != nil {
+				return err
+			}
+			backups = backups[1:]
+		}
+	}
+
+	return w.open()
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/issue9/assert"
+)
+
+func TestAccessLog_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	l := &accessLog{Path: "access.log"}
+	a.NotError(l.sanitize())
+	a.Equal(l.Format, accessLogCombined)
+
+	l.Format = "xml"
+	a.Equal(l.sanitize().Field, "accessLog.format")
+
+	l.Format = accessLogJSON
+	l.MaxSize = -1
+	a.Equal(l.sanitize().Field, "accessLog.maxSize")
+}
+
+func TestAccessLogHandler(t *testing.T) {
+	a := assert.New(t)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	})
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/posts/p1.html?page=2", nil)
+		r.RemoteAddr = "1.1.1.1:1234"
+		r.Header.Set("Referer", "https://example.com/")
+		r.Header.Set("User-Agent", `agent "1"`)
+		return r
+	}
+
+	buf := new(bytes.Buffer)
+	accessLogHandler(h, buf, accessLogCombined).ServeHTTP(httptest.NewRecorder(), newRequest())
+	line := buf.String()
+	a.True(strings.HasPrefix(line, "1.1.1.1 - - ["), line)
+	a.True(strings.HasSuffix(line, `] "GET /posts/p1.html?page=2 HTTP/1.1" 404 9 "https://example.com/" "agent \"1\""`+"\n"), line)
+
+	buf.Reset()
+	accessLogHandler(h, buf, accessLogCommon).ServeHTTP(httptest.NewRecorder(), newRequest())
+	a.True(strings.HasSuffix(buf.String(), `] "GET /posts/p1.html?page=2 HTTP/1.1" 404 9`+"\n"))
+
+	buf.Reset()
+	accessLogHandler(h, buf, accessLogJSON).ServeHTTP(httptest.NewRecorder(), newRequest())
+	e := &accessEntry{}
+	a.NotError(json.Unmarshal(buf.Bytes(), e))
+	a.Equal(e.IP, "1.1.1.1").
+		Equal(e.Status, http.StatusNotFound).
+		Equal(e.Bytes, 9).
+		Equal(e.URI, "/posts/p1.html?page=2").
+		Equal(e.UserAgent, `agent "1"`)
+}
+
+func TestRotateWriter(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "gitype-access")
+	a.NotError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "logs", "access.log")
+	w, err := newRotateWriter(file, 10, 2)
+	a.NotError(err)
+
+	for i := 0; i < 5; i++ {
+		_, err = w.Write([]byte("12345678\n"))
+		a.NotError(err)
+	}
+
+	backups, err := filepath.Glob(file + ".*")
+	a.NotError(err)
+	a.Equal(len(backups), 2)
+
+	content, err := ioutil.ReadFile(file)
+	a.NotError(err)
+	a.Equal(string(content), "12345678\n")
+}
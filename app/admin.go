@@ -0,0 +1,261 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/caixw/gitype/analytics"
+	"github.com/caixw/gitype/data"
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/logs"
+)
+
+// 管理后台中手动重新加载数据的地址，相对于 admin.url
+const adminReloadURL = "/reload"
+
+// 管理后台中显示的热门文章和来源网站的数量
+const adminStatsSize = 20
+
+// 程序运行的状态信息，用于在管理后台显示。
+type status struct {
+	locker sync.RWMutex
+
+	webhook       time.Time // 最后一次执行 webhook 的时间
+	webhookOutput string    // 最后一次执行 webhook 时 git 命令的输出
+	webhookError  string    // 最后一次执行 webhook 的错误信息，为空表示执行成功
+
+	reload      time.Time // 最后一次加载数据的时间
+	reloadError string    // 最后一次加载数据的错误信息，为空表示加载成功
+
+	commit string // 最后一次成功加载时，数据仓库的提交，仅在启用了回滚功能时才有值
+}
+
+// 管理后台页面的数据
+type adminPage struct {
+	AppName    string
+	AppVersion string
+	CSRF       string // 防止跨站请求的令牌
+	ReloadURL  string
+	LinksURL   string // 链接检测报告的地址，为空表示未启用
+
+	Webhook       time.Time
+	WebhookOutput string
+	WebhookError  string
+	Reload        time.Time
+	ReloadError   string
+
+	// 当前数据的信息，数据未加载成功时为空
+	Data *data.Data
+
+	// 访问统计，未启用时为空
+	Analytics    *analytics.Analytics
+	TopPosts     []*analytics.Count
+	TopReferrers []*analytics.Count
+}
+
+var adminTemplate = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"T": locale.Translate,
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8" />
+<title>{{.AppName}} {{T "管理后台"}}</title>
+</head>
+<body>
+<h1>{{.AppName}} {{.AppVersion}}</h1>
+
+<h2>{{T "数据"}}</h2>
+{{with .Data}}
+<dl>
+<dt>{{T "加载时间"}}</dt><dd>{{date .Created}}</dd>
+<dt>{{T "文章数量"}}</dt><dd class="posts">{{len .Posts}}</dd>
+<dt>{{T "标签数量"}}</dt><dd class="tags">{{len .Tags}}</dd>
+<dt>{{T "专题数量"}}</dt><dd class="series">{{len .Series}}</dd>
+<dt>{{T "主题"}}</dt><dd class="theme">{{.Theme.Name}} ({{.Theme.ID}}) {{.Theme.Version}}</dd>
+</dl>
+{{else}}
+<p class="error">{{T "数据未加载"}}</p>
+{{end}}
+
+<h2>{{T "最后一次加载"}}</h2>
+<p>{{date .Reload}}</p>
+{{if .ReloadError}}<pre class="error reload-error">{{.ReloadError}}</pre>{{end}}
+<form method="post" action="{{.ReloadURL}}">
+<input type="hidden" name="csrf" value="{{.CSRF}}" />
+<button type="submit">{{T "立即重新加载"}}</button>
+</form>
+
+<h2>{{T "最后一次 webhook"}}</h2>
+<p>{{date .Webhook}}</p>
+{{if .WebhookError}}<pre class="error webhook-error">{{.WebhookError}}</pre>{{end}}
+{{if .WebhookOutput}}<pre class="webhook-output">{{.WebhookOutput}}</pre>{{end}}
+
+{{with .Analytics}}
+<h2>{{T "访问统计"}}</h2>
+<p>{{T "开始统计的时间"}}: {{date .Since}}</p>
+<table class="posts-stats">
+<tr><th>{{T "文章"}}</th><th>{{T "浏览量"}}</th><th>{{T "访客"}}</th></tr>
+{{range $.TopPosts}}<tr><td>{{.Name}}</td><td>{{.Views}}</td><td>{{.Visitors}}</td></tr>
+{{end}}</table>
+<table class="referrers-stats">
+<tr><th>{{T "来源"}}</th><th>{{T "浏览量"}}</th></tr>
+{{range $.TopReferrers}}<tr><td>{{.Name}}</td><td>{{.Views}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .LinksURL}}<p><a href="{{.LinksURL}}">{{T "链接检测报告"}}</a></p>{{end}}
+</body>
+</html>
+`))
+
+func (s *status) reloaded(err error) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.reload = time.Now()
+	s.reloadError = ""
+	if err != nil {
+		s.reloadError = err.Error()
+	}
+}
+
+func (s *status) setCommit(commit string) {
+	s.locker.Lock()
+	s.commit = commit
+	s.locker.Unlock()
+}
+
+func (s *status) lastCommit() string {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+	return s.commit
+}
+
+func (s *status) lastWebhook() time.Time {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+	return s.webhook
+}
+
+func (s *status) webhooked(output string, err error) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.webhook = time.Now()
+	s.webhookOutput = output
+	s.webhookError = ""
+	if err != nil {
+		s.webhookError = err.Error()
+	}
+}
+
+// 初始化管理后台的路由
+func (a *app) initAdmin() error {
+	a.csrf = newCSRFToken()
+
+	conf := a.conf.Admin
+	if err := a.handleFunc(conf.URL, a.authenticate(a.getAdmin), http.MethodGet); err != nil {
+		return err
+	}
+
+	return a.handleFunc(conf.URL+adminReloadURL, a.authenticate(a.postAdminReload), http.MethodPost)
+}
+
+// 使用 HTTP Basic 验证，未启用管理后台时，直接返回 h。
+func (a *app) authenticate(h http.HandlerFunc) http.HandlerFunc {
+	conf := a.conf.Admin
+	if conf == nil {
+		return h
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(conf.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(conf.Password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+vars.Name+`"`)
+			helper.StatusError(w, http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		h(w, r)
+	}
+}
+
+// 管理后台的首页
+func (a *app) getAdmin(w http.ResponseWriter, r *http.Request) {
+	a.status.locker.RLock()
+	p := &adminPage{
+		AppName:    vars.Name,
+		AppVersion: vars.Version(),
+		CSRF:       a.csrf,
+		ReloadURL:  a.conf.Admin.URL + adminReloadURL,
+
+		Webhook:       a.status.webhook,
+		WebhookOutput: a.status.webhookOutput,
+		WebhookError:  a.status.webhookError,
+		Reload:        a.status.reload,
+		ReloadError:   a.status.reloadError,
+	}
+	a.status.locker.RUnlock()
+
+	if a.conf.LinkCheck != nil {
+		p.LinksURL = a.conf.LinkCheck.URL
+	}
+
+	if c := a.getClient(); c != nil {
+		p.Data = c.Data()
+	}
+
+	if a.stats != nil {
+		p.Analytics = a.stats
+		p.TopPosts = a.stats.TopPosts(adminStatsSize)
+		p.TopReferrers = a.stats.TopReferrers(adminStatsSize)
+	}
+
+	w.Header().Set("Content-Type", "text/html;charset=utf-8")
+	if err := adminTemplate.Execute(w, p); err != nil {
+		logs.Error(err)
+	}
+}
+
+// 手动重新加载数据，完成之后返回管理后台的首页
+func (a *app) postAdminReload(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("csrf")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(a.csrf)) != 1 {
+		helper.StatusError(w, http.StatusForbidden)
+		return
+	}
+
+	if err := a.reload(); err != nil { // 错误信息会显示在管理后台中
+		logs.Error(err)
+	}
+
+	http.Redirect(w, r, a.conf.Admin.URL, http.StatusSeeOther)
+}
+
+func newCSRFToken() string {
+	bs := make([]byte, 16)
+	if _, err := rand.Read(bs); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(bs)
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/caixw/gitype/analytics"
+	"github.com/issue9/assert"
+)
+
+func newAdminApp(a *assert.Assertion) *app {
+	app := newTestApp(&config{
+		Admin: &admin{URL: "/admin", Username: "admin", Password: "123"},
+	})
+	a.NotError(app.initAdmin())
+	a.NotEmpty(app.csrf)
+
+	return app
+}
+
+func TestApp_authenticate(t *testing.T) {
+	a := assert.New(t)
+	app := newAdminApp(a)
+
+	r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	w := httptest.NewRecorder()
+	app.mux.ServeHTTP(w, r)
+	a.Equal(w.Code, http.StatusUnauthorized)
+	a.NotEmpty(w.Header().Get("WWW-Authenticate"))
+
+	r.SetBasicAuth("admin", "456")
+	w = httptest.NewRecorder()
+	app.mux.ServeHTTP(w, r)
+	a.Equal(w.Code, http.StatusUnauthorized)
+
+	r.SetBasicAuth("admin", "123")
+	w = httptest.NewRecorder()
+	app.mux.ServeHTTP(w, r)
+	a.Equal(w.Code, http.StatusOK)
+
+	// 未启用管理后台，不需要验证
+	app.conf.Admin = nil
+	w = httptest.NewRecorder()
+	app.authenticate(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	a.Equal(w.Code, http.StatusAccepted)
+}
+
+func TestApp_getAdmin(t *testing.T) {
+	a := assert.New(t)
+	app := newAdminApp(a)
+
+	get := func() string {
+		r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		r.SetBasicAuth("admin", "123")
+		w := httptest.NewRecorder()
+		app.mux.ServeHTTP(w, r)
+		a.Equal(w.Code, http.StatusOK)
+		return w.Body.String()
+	}
+
+	// 数据未加载
+	body := get()
+	a.True(strings.Contains(body, `class="error"`))
+	a.True(strings.Contains(body, app.csrf))
+
+	app.status.webhooked("Already up-to-date.", errors.New("exit status 1"))
+	a.NotError(app.reload())
+	body = get()
+	a.True(strings.Contains(body, `<dd class="posts">3</dd>`))
+	a.True(strings.Contains(body, `<dd class="theme">`))
+	a.True(strings.Contains(body, "Already up-to-date."))
+	a.True(strings.Contains(body, "exit status 1"))
+	a.False(strings.Contains(body, "reload-error"))
+	a.False(strings.Contains(body, "posts-stats"))
+
+	// 访问统计
+	stats, err := analytics.New("./not-exists.json")
+	a.NotError(err)
+	r := httptest.NewRequest(http.MethodGet, "/posts/post1.html", nil)
+	r.Header.Set("User-Agent", "Mozilla/5.0")
+	stats.Hit(r, "post1")
+	app.stats = stats
+	body = get()
+	a.True(strings.Contains(body, "<tr><td>post1</td><td>1</td><td>1</td></tr>"))
+}
+
+func TestApp_postAdminReload(t *testing.T) {
+	a := assert.New(t)
+	app := newAdminApp(a)
+
+	post := func(token string) *httptest.ResponseRecorder {
+		form := url.Values{"csrf": []string{token}}
+		r := httptest.NewRequest(http.MethodPost, "/admin/reload", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.SetBasicAuth("admin", "123")
+		w := httptest.NewRecorder()
+		app.mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post("invalid")
+	a.Equal(w.Code, http.StatusForbidden)
+	a.Nil(app.getClient())
+
+	w = post(app.csrf)
+	a.Equal(w.Code, http.StatusSeeOther)
+	a.Equal(w.Header().Get("Location"), "/admin")
+	a.NotNil(app.getClient())
+	a.False(app.status.reload.IsZero())
+	a.Empty(app.status.reloadError)
+}
+
+func TestAdmin_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	ad := &admin{URL: "/admin", Username: "u", Password: "p"}
+	a.NotError(ad.sanitize())
+
+	ad.URL = "/admin/"
+	a.Equal(ad.sanitize().Field, "admin.url")
+
+	ad.URL = "/"
+	a.Equal(ad.sanitize().Field, "admin.url")
+
+	ad.URL = "/admin"
+	ad.Password = ""
+	a.Equal(ad.sanitize().Field, "admin.password")
+}
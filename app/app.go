@@ -7,30 +7,66 @@ package app
 
 import (
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/caixw/gitype/analytics"
 	"github.com/caixw/gitype/client"
+	"github.com/caixw/gitype/data"
+	"github.com/caixw/gitype/locale"
+	"github.com/caixw/gitype/metrics"
 	"github.com/caixw/gitype/path"
 	"github.com/issue9/logs"
 	"github.com/issue9/mux"
 )
 
 type app struct {
-	path   *path.Path
-	mux    *mux.Mux
-	conf   *config
-	client *client.Client
+	path    *path.Path
+	mux     *mux.Mux
+	conf    *config
+	client  atomic.Value         // 当前的 *client.Client，通过 getClient 获取
+	links   *data.LinkChecker    // 为空表示未启用链接检测
+	reports linkReports          // 链接检测的报告
+	stats   *analytics.Analytics // 为空表示未启用访问统计
+	status  status
+	csrf    string // 管理后台的防跨站请求令牌
+
+	// HTTP/3 服务是否已经退出，退出之后不再输出 Alt-Svc 报头，通过 atomic 操作。
+	http3Stopped int32
+
+	// 保证同一时间只有一个加载数据的操作，
+	// webhooks 和管理后台都可以触发重新加载。
+	reloadLocker sync.Mutex
+
+	// 程序级别的路由项，数据未加载成功时，这些路由依然可以访问。
+	patterns []string
+
+	// 所有通过 newServer 声明的服务，退出时需要逐个关闭。
+	servers       []*http.Server
+	serversLocker sync.Mutex
 }
 
 // Run 运行程序
 func Run(path *path.Path, pprof bool) error {
-	logs.Info("程序工作路径为:", path.Root)
+	logs.Info(locale.Translate("程序工作路径为:"), path.Root)
 
 	conf, err := loadConfig(path)
 	if err != nil {
 		return err
 	}
 
+	if len(conf.Locale) > 0 {
+		if err = locale.Set(conf.Locale); err != nil {
+			return err
+		}
+	}
+
 	a := &app{
 		path: path,
 		mux:  mux.New(false, false, nil, nil),
@@ -38,57 +74,200 @@ func Run(path *path.Path, pprof bool) error {
 	}
 
 	// 初始化 webhooks
-	err = a.mux.HandleFunc(a.conf.Webhook.URL, a.postWebhooks, a.conf.Webhook.Method)
+	err = a.handleFunc(a.conf.Webhook.URL, a.postWebhooks, a.conf.Webhook.Method)
 	if err != nil {
 		return err
 	}
 
-	// 加载数据，此时出错，只记录错误信息，但不中断执行
+	// 存活检测、就绪检测和运行指标
+	if err = a.initProbes(); err != nil {
+		return err
+	}
+
+	// 健康检测
+	if len(a.conf.Health) > 0 {
+		if err = a.handleFunc(a.conf.Health, a.getHealth, http.MethodGet); err != nil {
+			return err
+		}
+	}
+
+	// 链接检测的报告
+	if l := a.conf.LinkCheck; l != nil {
+		a.links = data.NewLinkChecker(l.External, l.Concurrency, l.Timeout, l.CacheTTL)
+		if err = a.handleFunc(l.URL, a.authenticate(a.getLinks), http.MethodGet); err != nil {
+			return err
+		}
+	}
+
+	// 访问统计
+	if an := a.conf.Analytics; an != nil {
+		file := an.Path
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(a.path.Root, file)
+		}
+		if a.stats, err = analytics.New(file); err != nil {
+			return err
+		}
+		a.stats.Run(an.Interval, func(err error) { logs.Error(err) })
+	}
+
+	// 管理后台
+	if a.conf.Admin != nil {
+		if err = a.initAdmin(); err != nil {
+			return err
+		}
+	}
+
+	// 加载数据，此时出错，只记录错误信息，但不中断执行，
+	// 在数据加载成功之前，所有非程序级别的路由都显示维护页面。
 	if err = a.reload(); err != nil {
 		logs.Error(err)
 	}
 
-	h := a.buildHandler(pprof)
+	h, err := a.buildHandler(pprof)
+	if err != nil {
+		return err
+	}
+
+	return a.run(h)
+}
+
+// 运行服务，直到服务出错或是收到退出信号。
+//
+// 收到 SIGINT 或 SIGTERM 时，等待正在处理的请求完成之后再关闭服务，
+// 之后保存访问统计的数据，否则最后一次保存之后的数据都会丢失。
+// 正常退出时返回 nil。
+func (a *app) run(h http.Handler) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	errs := make(chan error, 1)
+	go func() {
+		if !a.conf.HTTPS {
+			errs <- a.serve(h, nil)
+			return
+		}
+
+		go a.serveHTTP(h) // 对 80 端口的处理方式
+		errs <- a.serveTLS(h)
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case sig := <-signals:
+		logs.Info(locale.Translate("收到退出信号："), sig)
+		err = a.shutdown()
+	}
+
+	if a.stats != nil {
+		if e := a.stats.Save(); e != nil {
+			logs.Error(e)
+		}
+	}
 
-	if !a.conf.HTTPS {
-		return http.ListenAndServe(a.conf.Port, h)
+	return err
+}
+
+// 注册程序级别的路由
+func (a *app) handleFunc(pattern string, h http.HandlerFunc, methods ...string) error {
+	if err := a.mux.HandleFunc(pattern, h, methods...); err != nil {
+		return err
 	}
 
-	go a.serveHTTP(h) // 对 80 端口的处理方式
-	return http.ListenAndServeTLS(a.conf.Port, a.conf.CertFile, a.conf.KeyFile, h)
+	a.patterns = append(a.patterns, pattern)
+	return nil
 }
 
 // 对 80 端口的处理方式
 func (a *app) serveHTTP(h http.Handler) {
 	switch a.conf.HTTPState {
 	case httpStateDefault:
-		logs.Error(http.ListenAndServe(httpPort, h))
 	case httpStateRedirect:
-		logs.Error(http.ListenAndServe(httpPort, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// 构建跳转链接
 			url := r.URL
 			url.Scheme = "HTTPS"
 			url.Host = strings.Split(r.Host, ":")[0] + a.conf.Port
 
 			http.Redirect(w, r, url.String(), http.StatusMovedPermanently)
-		})))
+		})
+	default:
+		return
 	} // end switch
+
+	srv := a.newServer(h, nil)
+	srv.Addr = httpPort
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		logs.Error(err)
+	}
+}
+
+// 获取当前的 client 实例，数据未加载成功时返回 nil。
+//
+// 在重新加载数据时，可能会被替换，所以同一请求中应该只获取一次。
+func (a *app) getClient() *client.Client {
+	c, _ := a.client.Load().(*client.Client)
+	return c
 }
 
 // 重新加载数据
+//
+// 新数据加载失败时，继续使用旧的数据，并返回错误信息。
 func (a *app) reload() error {
-	if a.client != nil { // 释放旧数据
-		a.client.Free()
-	}
+	a.reloadLocker.Lock()
+	defer a.reloadLocker.Unlock()
+
+	return a.load()
+}
+
+// 加载数据，调用者需要持有 reloadLocker。
+func (a *app) load() (err error) {
+	start := time.Now()
+	defer func() {
+		a.status.reloaded(err)
+
+		metrics.ReloadDuration.Since(start)
+		if err != nil {
+			metrics.Reloads.Inc(metrics.ResultFailure)
+		} else {
+			metrics.Reloads.Inc(metrics.ResultSuccess)
+		}
+	}()
 
 	// 生成新的数据
-	c, err := client.New(a.path, a.mux)
+	c, err := client.Load(a.path, a.mux)
 	if err != nil {
 		return err
 	}
 
+	if a.stats != nil {
+		c.SetAnalytics(a.stats, a.conf.Analytics.Popular)
+	}
+
+	old := a.getClient()
+	if old != nil { // 释放旧数据的路由
+		old.Free()
+	}
+
+	if err = c.Mount(); err != nil {
+		if old != nil && old.Mount() != nil { // 旧数据也无法恢复
+			a.client.Store((*client.Client)(nil))
+		}
+		return err
+	}
+
 	// 只有生成成功了，才替换老数据
-	a.client = c
+	a.client.Store(c)
+
+	if a.conf.Webhook.Rollback {
+		commit, err := a.headCommit()
+		if err != nil { // 仅影响回滚功能，不作为加载失败处理
+			logs.Error(err)
+		}
+		a.status.setCommit(commit)
+	}
 
 	return nil
 }
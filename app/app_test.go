@@ -0,0 +1,57 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/caixw/gitype/path"
+	"github.com/issue9/assert"
+	"github.com/issue9/mux"
+)
+
+// 声明一个用于测试的 app 实例，数据目录为 ../testdata/，
+// conf.Webhook 未指定时，使用空值。
+func newTestApp(conf *config) *app {
+	if conf.Webhook == nil {
+		conf.Webhook = &webhook{}
+	}
+
+	return &app{
+		path: path.New("../testdata/"),
+		mux:  mux.New(false, false, nil, nil),
+		conf: conf,
+	}
+}
+
+func TestApp_reload(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&config{})
+	a.Nil(app.getClient())
+
+	// 同时触发多个加载操作，且在加载过程中一直可以获取到数据
+	a.NotError(app.reload())
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			a.NotError(app.reload())
+		}()
+		go func() {
+			defer wg.Done()
+			a.NotNil(app.getClient())
+		}()
+	}
+	wg.Wait()
+	a.NotNil(app.getClient())
+
+	// 加载失败，依然使用旧数据
+	old := app.getClient()
+	app.path = path.New("./not-exists")
+	a.Error(app.reload())
+	a.Equal(app.getClient(), old)
+}
@@ -6,10 +6,12 @@ package app
 
 import (
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
 	"github.com/caixw/gitype/path"
 	"github.com/issue9/is"
 	"github.com/issue9/utils"
@@ -21,6 +23,15 @@ const (
 	httpsPort = ":443"
 )
 
+// 各超时时间的默认值
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultWriteTimeout      = 60 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultShutdownTimeout   = 30 * time.Second
+)
+
 // 对 Config.HTTPState 可选值的定义
 const (
 	httpStateDefault  = "default"
@@ -28,7 +39,7 @@ const (
 	httpStateRedirect = "redirect"
 )
 
-//  程序的运行环境配置内容
+// 程序的运行环境配置内容
 type config struct {
 	// 是否启用 HTTPS 模式。如果启用了，则需要正确设置以下几个值：
 	// HTTPState、CertFile、KeyFile
@@ -44,10 +55,27 @@ type config struct {
 
 	KeyFile string `yaml:"keyFile,omitempty"`
 
-	// 监听的端口，需要带前缀冒号(:)，不指定时，
-	// 根据 HTTPS 的值，默认为 :80 或是 :443
+	// HTTPS 的相关配置，比如 TLS 版本、加密套件和 HTTP/3 等，
+	// 仅在 HTTPS 为 true 时有效，为空表示使用默认值。
+	TLS *tlsConfig `yaml:"tls,omitempty"`
+
+	// 监听的地址，可以是以下格式：
+	// :443 或是 127.0.0.1:443 表示 TCP 地址；
+	// unix:/run/gitype.sock 表示 Unix socket；
+	// systemd 表示使用 systemd 的 socket activation 传递过来的所有 socket。
+	// 不指定时，根据 HTTPS 的值，默认为 :80 或是 :443
 	Port string `yaml:"port,omitempty"`
 
+	// 除 Port 之外，其它需要同时监听的地址，格式与 Port 相同。
+	Listen []string `yaml:"listen,omitempty"`
+
+	// Unix socket 文件的权限，八进制格式，默认为 0660。
+	SocketMode string `yaml:"socketMode,omitempty"`
+	socketMode os.FileMode
+
+	// 服务的超时时间，对所有的监听地址以及 80 端口都有效，为空表示使用默认值。
+	Timeouts *timeouts `yaml:"timeouts,omitempty"`
+
 	// 绑定的域名，若指定了该值，则只能通过这些域名才能访问网站。
 	// 为空表示不作限制。
 	Domains []string `yaml:"domains,omitempty"`
@@ -56,7 +84,51 @@ type config struct {
 	// 其中键名表示报头名称，键值表示报头的值。
 	Headers map[string]string `yaml:"headers,omitempty"`
 
+	// 安全相关的报头，包括 HSTS、CSP 等，为空表示不输出这些报头。
+	Security *security `yaml:"security,omitempty"`
+
 	Webhook *webhook `yaml:"webhook"`
+
+	// 管理后台的相关配置，为空表示不启用管理后台。
+	// 启用之后，链接检测的报告也需要登录才能查看。
+	Admin *admin `yaml:"admin,omitempty"`
+
+	// 链接检测的相关配置，为空表示不提供链接检测的报告。
+	LinkCheck *linkCheck `yaml:"linkCheck,omitempty"`
+
+	// 健康检测的地址，输出 JSON 格式的运行状态，为空表示不启用。
+	Health string `yaml:"health,omitempty"`
+
+	// 受信任的代理服务器，可以是 IP 或是 CIDR。
+	// 只有来自这些地址的请求，才会从 X-Forwarded-For 报头中获取客户端的真实 IP。
+	TrustedProxies []string `yaml:"trustedProxies,omitempty"`
+	proxies        ipNets
+
+	// 访问限制的相关配置，为空表示不作限制。
+	RateLimit *rateLimit `yaml:"rateLimit,omitempty"`
+
+	// 访问日志的相关配置，为空表示不输出访问日志。
+	AccessLog *accessLog `yaml:"accessLog,omitempty"`
+
+	// 访问统计的相关配置，为空表示不启用。
+	Analytics *analyticsConfig `yaml:"analytics,omitempty"`
+
+	// 是否在 /metrics 输出 Prometheus 格式的运行指标。
+	// /healthz 和 /readyz 始终可用，不受此值影响。
+	Metrics bool `yaml:"metrics,omitempty"`
+
+	// 程序输出内容（命令行、错误信息和日志）所使用的语言，比如 en。
+	// 为空表示根据环境变量 LC_ALL、LC_MESSAGES 和 LANG 决定。
+	Locale string `yaml:"locale,omitempty"`
+}
+
+// 对应 http.Server 中的各个超时时间，防止慢速的客户端一直占用连接。
+type timeouts struct {
+	ReadHeader time.Duration `yaml:"readHeader,omitempty"` // 读取报头的超时时间，默认为 10 秒
+	Read       time.Duration `yaml:"read,omitempty"`       // 读取整个请求的超时时间，默认为 30 秒
+	Write      time.Duration `yaml:"write,omitempty"`      // 输出内容的超时时间，默认为 60 秒
+	Idle       time.Duration `yaml:"idle,omitempty"`       // keep-alive 连接的空闲时间，默认为 120 秒
+	Shutdown   time.Duration `yaml:"shutdown,omitempty"`   // 退出时等待请求完成的时间，默认为 30 秒
 }
 
 type webhook struct {
@@ -64,6 +136,30 @@ type webhook struct {
 	Frequency time.Duration `yaml:"frequency"`        // webhooks 的最小更新频率
 	Method    string        `yaml:"method,omitempty"` // webhooks 的请求方式，默认为 POST
 	RepoURL   string        `yaml:"repoURL"`          // 远程仓库的地址
+
+	// 更新之后加载失败时，是否将数据仓库回滚到最后一次成功加载时的提交。
+	// 无论是否回滚，加载失败时都会继续使用旧的数据。
+	Rollback bool `yaml:"rollback,omitempty"`
+}
+
+type admin struct {
+	URL      string `yaml:"url"`      // 管理后台的地址，比如 /admin
+	Username string `yaml:"username"` // 登录的账号，使用 HTTP Basic 验证
+	Password string `yaml:"password"` // 登录的密码
+}
+
+type linkCheck struct {
+	URL         string        `yaml:"url"`                   // 检测报告的地址，输出 JSON 格式的内容
+	External    bool          `yaml:"external,omitempty"`    // 是否检测外链
+	Concurrency int           `yaml:"concurrency,omitempty"` // 同时检测外链的最大数量，默认为 5
+	Timeout     time.Duration `yaml:"timeout,omitempty"`     // 外链的请求超时时间，默认为 10 秒
+	CacheTTL    time.Duration `yaml:"cacheTTL,omitempty"`    // 外链检测结果的缓存时间，默认为 24 小时
+}
+
+type analyticsConfig struct {
+	Path     string        `yaml:"path"`               // 统计数据的保存路径，相对路径表示相对于程序的工作目录
+	Popular  int           `yaml:"popular,omitempty"`  // 模板中热门文章的数量，默认为 5
+	Interval time.Duration `yaml:"interval,omitempty"` // 保存数据的时间间隔，默认为 5 分钟
 }
 
 func loadConfig(path *path.Path) (*config, error) {
@@ -97,6 +193,87 @@ func (w *webhook) sanitize() *helper.FieldError {
 	return nil
 }
 
+func (ad *admin) sanitize() *helper.FieldError {
+	switch {
+	case len(ad.URL) <= 1 || ad.URL[0] != '/' || ad.URL[len(ad.URL)-1] == '/':
+		return &helper.FieldError{Field: "admin.url", Message: "必须以 / 开头，且不能以 / 结尾"}
+	case len(ad.Username) == 0:
+		return &helper.FieldError{Field: "admin.username", Message: "不能为空"}
+	case len(ad.Password) == 0:
+		return &helper.FieldError{Field: "admin.password", Message: "不能为空"}
+	}
+
+	return nil
+}
+
+func (an *analyticsConfig) sanitize() *helper.FieldError {
+	if an.Popular == 0 {
+		an.Popular = 5
+	}
+	if an.Interval == 0 {
+		an.Interval = 5 * time.Minute
+	}
+
+	switch {
+	case len(an.Path) == 0:
+		return &helper.FieldError{Field: "analytics.path", Message: "不能为空"}
+	case an.Popular < 0:
+		return &helper.FieldError{Field: "analytics.popular", Message: "不能小于 0"}
+	case an.Interval < 0:
+		return &helper.FieldError{Field: "analytics.interval", Message: "不能小于 0"}
+	}
+
+	return nil
+}
+
+func (t *timeouts) sanitize() *helper.FieldError {
+	if t.ReadHeader == 0 {
+		t.ReadHeader = defaultReadHeaderTimeout
+	}
+	if t.Read == 0 {
+		t.Read = defaultReadTimeout
+	}
+	if t.Write == 0 {
+		t.Write = defaultWriteTimeout
+	}
+	if t.Idle == 0 {
+		t.Idle = defaultIdleTimeout
+	}
+	if t.Shutdown == 0 {
+		t.Shutdown = defaultShutdownTimeout
+	}
+
+	switch {
+	case t.ReadHeader < 0:
+		return &helper.FieldError{Field: "timeouts.readHeader", Message: "不能小于 0"}
+	case t.Read < 0:
+		return &helper.FieldError{Field: "timeouts.read", Message: "不能小于 0"}
+	case t.Write < 0:
+		return &helper.FieldError{Field: "timeouts.write", Message: "不能小于 0"}
+	case t.Idle < 0:
+		return &helper.FieldError{Field: "timeouts.idle", Message: "不能小于 0"}
+	case t.Shutdown < 0:
+		return &helper.FieldError{Field: "timeouts.shutdown", Message: "不能小于 0"}
+	}
+
+	return nil
+}
+
+func (l *linkCheck) sanitize() *helper.FieldError {
+	switch {
+	case len(l.URL) == 0 || l.URL[0] != '/':
+		return &helper.FieldError{Field: "linkCheck.url", Message: "不能为空且只能以 / 开头"}
+	case l.Concurrency < 0:
+		return &helper.FieldError{Field: "linkCheck.concurrency", Message: "不能小于 0"}
+	case l.Timeout < 0:
+		return &helper.FieldError{Field: "linkCheck.timeout", Message: "不能小于 0"}
+	case l.CacheTTL < 0:
+		return &helper.FieldError{Field: "linkCheck.cacheTTL", Message: "不能小于 0"}
+	}
+
+	return nil
+}
+
 func (conf *config) sanitize() *helper.FieldError {
 	if len(conf.Port) == 0 {
 		if conf.HTTPS {
@@ -106,9 +283,46 @@ func (conf *config) sanitize() *helper.FieldError {
 		}
 	}
 
+	if err := checkAddress("port", conf.Port); err != nil {
+		return err
+	}
+	systemd := conf.Port == systemdAddress
+	for index, addr := range conf.Listen {
+		field := "listen[" + strconv.Itoa(index) + "]"
+		if err := checkAddress(field, addr); err != nil {
+			return err
+		}
+
+		// systemd 传递的 socket 只能获取一次
+		if addr == systemdAddress {
+			if systemd {
+				return &helper.FieldError{Field: field, Message: "systemd 只能指定一次"}
+			}
+			systemd = true
+		}
+	}
+
+	mode, err := parseSocketMode(conf.SocketMode)
+	if err != nil {
+		return err
+	}
+	conf.socketMode = mode
+
+	if conf.Timeouts == nil {
+		conf.Timeouts = &timeouts{}
+	}
+	if err := conf.Timeouts.sanitize(); err != nil {
+		return err
+	}
+
 	if conf.HTTPS {
+		// port 不是 TCP 地址时，默认不监听 80 端口
 		if len(conf.HTTPState) == 0 {
-			conf.HTTPState = httpStateDefault
+			if isTCPAddress(conf.Port) {
+				conf.HTTPState = httpStateDefault
+			} else {
+				conf.HTTPState = httpStateDisable
+			}
 		}
 
 		switch {
@@ -118,11 +332,24 @@ func (conf *config) sanitize() *helper.FieldError {
 			return &helper.FieldError{Field: "httpState", Message: "无效的取值"}
 		case conf.HTTPState != httpStateDisable && conf.Port == httpPort:
 			return &helper.FieldError{Field: "port", Message: "80 端口已经被被监听"}
+		case conf.HTTPState != httpStateDisable && !isTCPAddress(conf.Port): // 监听 80 端口以及跳转地址中都需要 TCP 端口
+			return &helper.FieldError{Field: "httpState", Message: "port 必须为 TCP 地址"}
 		case !utils.FileExists(conf.CertFile):
 			return &helper.FieldError{Field: "certFile", Message: "不能为空"}
 		case !utils.FileExists(conf.KeyFile):
 			return &helper.FieldError{Field: "keyFile", Message: "不能为空"}
 		}
+
+		if conf.TLS == nil {
+			conf.TLS = &tlsConfig{}
+		}
+		if err := conf.TLS.sanitize(); err != nil {
+			return err
+		}
+
+		if conf.TLS.HTTP3 && !isTCPAddress(conf.Port) { // Alt-Svc 中需要指定端口
+			return &helper.FieldError{Field: "tls.http3", Message: "port 必须为 TCP 地址"}
+		}
 	}
 
 	if len(conf.Domains) > 0 {
@@ -133,5 +360,60 @@ func (conf *config) sanitize() *helper.FieldError {
 		}
 	}
 
+	proxies, err := parseIPNets("trustedProxies", conf.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	conf.proxies = proxies
+
+	if conf.Security != nil {
+		if err := conf.Security.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	if conf.RateLimit != nil {
+		if err := conf.RateLimit.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	if conf.AccessLog != nil {
+		if err := conf.AccessLog.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	if len(conf.Health) > 0 && conf.Health[0] != '/' {
+		return &helper.FieldError{Field: "health", Message: "只能以 / 开头"}
+	}
+
+	if len(conf.Locale) > 0 && !locale.Supported(conf.Locale) {
+		return &helper.FieldError{Field: "locale", Message: "不支持的语言"}
+	}
+
+	if conf.Admin != nil {
+		if err := conf.Admin.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	if conf.LinkCheck != nil {
+		// 检测报告中包含了文件路径等信息，且检测外链会发起大量的请求，不能公开访问。
+		if conf.Admin == nil {
+			return &helper.FieldError{Field: "linkCheck", Message: "必须同时启用 admin"}
+		}
+
+		if err := conf.LinkCheck.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	if conf.Analytics != nil {
+		if err := conf.Analytics.sanitize(); err != nil {
+			return err
+		}
+	}
+
 	return conf.Webhook.sanitize()
 }
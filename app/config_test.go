@@ -21,3 +21,23 @@ func TestLoadConfig(t *testing.T) {
 	a.Equal(conf.Port, ":8080")
 	a.Equal(conf.Webhook.Frequency, time.Minute)
 }
+
+func TestConfig_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	conf, err := loadConfig(path.New("../testdata/"))
+	a.NotError(err).NotNil(conf)
+
+	// linkCheck 需要同时启用 admin
+	conf.Admin = nil
+	conf.LinkCheck = &linkCheck{URL: "/admin/links.json"}
+	a.Equal(conf.sanitize().Field, "linkCheck")
+	conf.Admin = &admin{URL: "/admin", Username: "admin", Password: "123"}
+	a.Nil(conf.sanitize())
+
+	// timeouts 未指定时使用默认值
+	a.Equal(conf.Timeouts.ReadHeader, defaultReadHeaderTimeout)
+	a.Equal(conf.Timeouts.Idle, defaultIdleTimeout)
+	conf.Timeouts = &timeouts{Write: -1}
+	a.Equal(conf.sanitize().Field, "timeouts.write")
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
 	"github.com/issue9/logs"
 	"github.com/issue9/middleware/host"
 	"github.com/issue9/middleware/recovery"
@@ -18,20 +19,32 @@ import (
 // 输出调试内容的地址，地址值固定，不能修改。
 const debugPprof = "/debug/pprof/"
 
-func (a *app) buildHandler(pprof bool) http.Handler {
-	h := a.buildDomains(a.buildHeader(a.mux))
+func (a *app) buildHandler(pprof bool) (http.Handler, error) {
+	h := a.buildSecurity(a.buildDomains(a.buildHeader(a.buildMaintenance(a.mux))))
+
+	// 访问限制在 recovery 之内，输出错误页面时的 panic 也能被捕获；
+	// 同时也在访问日志之内，被拒绝的请求也会被记录。
+	h = a.buildRateLimit(h)
 
 	h = recovery.New(h, func(w http.ResponseWriter, msg interface{}) {
 		logs.Error(msg)
 		helper.StatusError(w, http.StatusInternalServerError)
 	})
 
+	// 访问日志需要在 recovery 之外，才能记录到 500 错误；
+	// 同时又需要在 buildRealIP 之内，才能记录客户端的真实 IP。
+	h, err := a.buildAccessLog(h)
+	if err != nil {
+		return nil, err
+	}
+	h = a.buildRealIP(h)
+
 	if !pprof {
-		return h
+		return h, nil
 	}
 
 	// 将 pprof 包装在最外层
-	return a.buildPprof(h)
+	return a.buildPprof(h), nil
 }
 
 func (a *app) buildDomains(h http.Handler) http.Handler {
@@ -56,7 +69,7 @@ func (a *app) buildHeader(h http.Handler) http.Handler {
 }
 
 func (a *app) buildPprof(h http.Handler) http.Handler {
-	logs.Debug("开启了调试功能，地址为：", debugPprof)
+	logs.Debug(locale.Translate("开启了调试功能，地址为："), debugPprof)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !strings.HasPrefix(r.URL.Path, debugPprof) {
@@ -0,0 +1,70 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/issue9/logs"
+)
+
+// 健康检测中 status 字段的可选值
+const (
+	healthOK          = "ok"          // 数据正常
+	healthDegraded    = "degraded"    // 最后一次加载失败，但依然在使用旧的数据
+	healthUnavailable = "unavailable" // 数据从未加载成功
+)
+
+// 健康检测的输出内容
+type health struct {
+	Status      string     `json:"status"`
+	Created     *time.Time `json:"created,omitempty"`     // 当前数据的创建时间
+	Reload      time.Time  `json:"reload"`                // 最后一次加载数据的时间
+	ReloadError string     `json:"reloadError,omitempty"` // 最后一次加载数据的错误信息
+	Commit      string     `json:"commit,omitempty"`      // 最后一次成功加载时，数据仓库的提交
+}
+
+func (a *app) health() *health {
+	a.status.locker.RLock()
+	h := &health{
+		Status:      healthOK,
+		Reload:      a.status.reload,
+		ReloadError: a.status.reloadError,
+		Commit:      a.status.commit,
+	}
+	a.status.locker.RUnlock()
+
+	c := a.getClient()
+	switch {
+	case c == nil:
+		h.Status = healthUnavailable
+	case len(h.ReloadError) > 0:
+		h.Status = healthDegraded
+	}
+
+	if c != nil {
+		created := c.Created()
+		h.Created = &created
+	}
+
+	return h
+}
+
+// 输出健康检测的结果，数据从未加载成功时，返回 503。
+func (a *app) getHealth(w http.ResponseWriter, r *http.Request) {
+	h := a.health()
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if h.Status == healthUnavailable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if err := json.NewEncoder(w).Encode(h); err != nil {
+		logs.Error(err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caixw/gitype/path"
+	"github.com/issue9/assert"
+)
+
+func newHealthApp(a *assert.Assertion) *app {
+	app := newTestApp(&config{Health: "/health"})
+	a.NotError(app.handleFunc(app.conf.Health, app.getHealth, http.MethodGet))
+
+	return app
+}
+
+func TestApp_getHealth(t *testing.T) {
+	a := assert.New(t)
+	app := newHealthApp(a)
+
+	get := func(code int) *health {
+		w := httptest.NewRecorder()
+		app.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		a.Equal(w.Code, code)
+
+		h := &health{}
+		a.NotError(json.NewDecoder(w.Body).Decode(h))
+		return h
+	}
+
+	// 数据未加载
+	h := get(http.StatusServiceUnavailable)
+	a.Equal(h.Status, healthUnavailable).Nil(h.Created)
+
+	a.NotError(app.reload())
+	h = get(http.StatusOK)
+	a.Equal(h.Status, healthOK).NotNil(h.Created)
+	a.Empty(h.ReloadError)
+
+	// 加载失败，继续使用旧数据
+	old := app.getClient()
+	app.path = path.New("./not-exists")
+	a.Error(app.reload())
+	a.Equal(app.getClient(), old)
+	h = get(http.StatusOK)
+	a.Equal(h.Status, healthDegraded).NotNil(h.Created)
+	a.NotEmpty(h.ReloadError)
+
+	// 重新加载成功
+	app.path = path.New("../testdata/")
+	a.NotError(app.reload())
+	a.NotEqual(app.getClient(), old)
+	a.Equal(get(http.StatusOK).Status, healthOK)
+}
+
+func TestApp_buildMaintenance(t *testing.T) {
+	a := assert.New(t)
+	app := newHealthApp(a)
+	h := app.buildMaintenance(app.mux)
+
+	// 数据未加载，显示维护页面
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	a.Equal(w.Code, http.StatusServiceUnavailable)
+	a.NotEmpty(w.Header().Get("Retry-After"))
+	a.NotEmpty(w.Body.String())
+
+	// 程序级别的路由不受影响
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	a.Equal(w.Header().Get("Content-Type"), "application/json;charset=utf-8")
+
+	a.NotError(app.reload())
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	a.Equal(w.Code, http.StatusOK)
+}
@@ -0,0 +1,28 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build http3
+// +build http3
+
+package app
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// 是否编译了 HTTP/3 的支持
+const http3Supported = true
+
+// 在 addr 的 UDP 端口上运行 HTTP/3 服务
+func serveHTTP3(addr string, h http.Handler, conf *tls.Config) error {
+	srv := &http3.Server{
+		Addr:      addr,
+		Handler:   h,
+		TLSConfig: conf,
+	}
+	return srv.ListenAndServe()
+}
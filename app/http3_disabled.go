@@ -0,0 +1,24 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !http3
+// +build !http3
+
+package app
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"github.com/caixw/gitype/locale"
+)
+
+// 是否编译了 HTTP/3 的支持
+const http3Supported = false
+
+// 未指定 http3 编译标签时，HTTP/3 不可用。
+func serveHTTP3(addr string, h http.Handler, conf *tls.Config) error {
+	return errors.New(locale.Translate("未启用 HTTP/3，需要使用 http3 标签重新编译"))
+}
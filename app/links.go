@@ -0,0 +1,93 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/caixw/gitype/data"
+	"github.com/caixw/gitype/helper"
+	"github.com/issue9/logs"
+)
+
+// 链接检测的报告
+type linkReport struct {
+	Checked  *time.Time         `json:"checked,omitempty"` // 检测完成的时间，尚未完成时为空
+	External bool               `json:"external"`          // 是否检测了外链
+	Running  bool               `json:"running"`           // 是否有正在后台进行的检测
+	Broken   []*data.BrokenLink `json:"broken"`            // 无效的链接
+}
+
+// 外链的检测比较耗时，所以在后台进行，请求时只输出最后一次的检测报告。
+type linkReports struct {
+	locker  sync.Mutex
+	last    *linkReport // 最后一次完成的检测报告
+	running bool
+}
+
+// 输出链接检测的报告
+//
+// 检测外链时，每次请求都会在后台开始一次新的检测（已经在检测中的除外），
+// 并输出上一次的检测报告，从未完成过检测时，返回 202。
+func (a *app) getLinks(w http.ResponseWriter, r *http.Request) {
+	c := a.getClient()
+	if c == nil { // 数据未加载成功
+		helper.StatusError(w, http.StatusServiceUnavailable)
+		return
+	}
+
+	if !a.links.External {
+		writeLinkReport(w, http.StatusOK, a.checkLinks(c.Data()))
+		return
+	}
+
+	a.reports.locker.Lock()
+	if !a.reports.running {
+		a.reports.running = true
+		go func() {
+			report := a.checkLinks(c.Data())
+
+			a.reports.locker.Lock()
+			a.reports.last = report
+			a.reports.running = false
+			a.reports.locker.Unlock()
+		}()
+	}
+
+	report := &linkReport{External: true, Running: true}
+	if a.reports.last != nil {
+		*report = *a.reports.last
+		report.Running = a.reports.running
+	}
+	a.reports.locker.Unlock()
+
+	if report.Checked == nil {
+		writeLinkReport(w, http.StatusAccepted, report)
+		return
+	}
+	writeLinkReport(w, http.StatusOK, report)
+}
+
+func (a *app) checkLinks(d *data.Data) *linkReport {
+	broken := a.links.Check(d)
+	checked := time.Now()
+
+	return &linkReport{
+		Checked:  &checked,
+		External: a.links.External,
+		Broken:   broken,
+	}
+}
+
+func writeLinkReport(w http.ResponseWriter, code int, report *linkReport) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logs.Error(err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caixw/gitype/data"
+	"github.com/issue9/assert"
+)
+
+func TestApp_getLinks(t *testing.T) {
+	a := assert.New(t)
+
+	app := newTestApp(&config{})
+	app.links = data.NewLinkChecker(false, 0, 0, 0)
+
+	// 数据未加载
+	w := httptest.NewRecorder()
+	app.getLinks(w, httptest.NewRequest(http.MethodGet, "/admin/links.json", nil))
+	a.Equal(w.Code, http.StatusServiceUnavailable)
+
+	a.NotError(app.reload())
+	w = httptest.NewRecorder()
+	app.getLinks(w, httptest.NewRequest(http.MethodGet, "/admin/links.json", nil))
+	a.Equal(w.Code, http.StatusOK)
+
+	report := &linkReport{}
+	a.NotError(json.Unmarshal(w.Body.Bytes(), report))
+	a.False(report.External)
+	a.Equal(len(report.Broken), 5)
+}
+
+func TestApp_getLinks_external(t *testing.T) {
+	a := assert.New(t)
+
+	app := newTestApp(&config{})
+	app.links = data.NewLinkChecker(true, 0, time.Millisecond, 0)
+	a.NotError(app.reload())
+
+	get := func(code int) *linkReport {
+		w := httptest.NewRecorder()
+		app.getLinks(w, httptest.NewRequest(http.MethodGet, "/admin/links.json", nil))
+		a.Equal(w.Code, code)
+
+		report := &linkReport{}
+		a.NotError(json.Unmarshal(w.Body.Bytes(), report))
+		return report
+	}
+
+	// 第一次访问，在后台开始检测
+	report := get(http.StatusAccepted)
+	a.True(report.Running).Nil(report.Checked)
+
+	for i := 0; i < 100; i++ {
+		app.reports.locker.Lock()
+		running := app.reports.running
+		app.reports.locker.Unlock()
+		if !running {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// 输出上一次的检测报告
+	report = get(http.StatusOK)
+	a.NotNil(report.Checked).True(report.External)
+	a.True(len(report.Broken) >= 5)
+}
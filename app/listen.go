@@ -0,0 +1,252 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
+	"github.com/issue9/logs"
+)
+
+// 监听地址的特殊格式
+const (
+	unixPrefix     = "unix:"   // unix:/run/gitype.sock 表示 Unix socket
+	systemdAddress = "systemd" // 表示使用 systemd 传递过来的所有 socket
+)
+
+// systemd 传递的第一个文件描述符，0-2 分别为标准输入、输出和错误。
+const systemdFirstFD = 3
+
+// Unix socket 文件的默认权限
+const defaultSocketMode = 0660
+
+// 检测监听地址的格式是否正确，field 为出错时的字段名。
+func checkAddress(field, addr string) *helper.FieldError {
+	switch {
+	case addr == systemdAddress:
+		return nil
+	case strings.HasPrefix(addr, unixPrefix):
+		if len(addr) == len(unixPrefix) {
+			return &helper.FieldError{Field: field, Message: "无效的监听地址"}
+		}
+		return nil
+	}
+
+	if _, port, err := net.SplitHostPort(addr); err != nil || len(port) == 0 {
+		return &helper.FieldError{Field: field, Message: "无效的监听地址"}
+	}
+	return nil
+}
+
+// 是否为 TCP 地址
+func isTCPAddress(addr string) bool {
+	return addr != systemdAddress && !strings.HasPrefix(addr, unixPrefix)
+}
+
+// 解析 socketMode 的值，为空时返回默认值。
+func parseSocketMode(mode string) (os.FileMode, *helper.FieldError) {
+	if len(mode) == 0 {
+		return defaultSocketMode, nil
+	}
+
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m > 0777 {
+		return 0, &helper.FieldError{Field: "socketMode", Message: "无效的取值"}
+	}
+	return os.FileMode(m), nil
+}
+
+// 根据 port 和 listen 生成所有的监听器
+func (a *app) listeners() ([]net.Listener, error) {
+	addrs := append([]string{a.conf.Port}, a.conf.Listen...)
+	listeners := make([]net.Listener, 0, len(addrs))
+
+	for _, addr := range addrs {
+		ls, err := a.listen(addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, ls...)
+	}
+
+	return listeners, nil
+}
+
+func (a *app) listen(addr string) ([]net.Listener, error) {
+	switch {
+	case addr == systemdAddress:
+		return systemdListeners()
+	case strings.HasPrefix(addr, unixPrefix):
+		l, err := listenUnix(addr[len(unixPrefix):], a.conf.socketMode)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return []net.Listener{l}, nil
+}
+
+// 监听 Unix socket，并设置文件权限。
+//
+// 程序异常退出时，socket 文件不会被删除，所以在监听之前需要先删除已经存在的文件。
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if stat, err := os.Stat(path); err == nil && stat.Mode()&os.ModeSocket != 0 {
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.Chmod(path, mode); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// 获取 systemd 通过 socket activation 传递过来的监听器。
+//
+// 具体规则可参考 sd_listen_fds(3)。
+func systemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New(locale.Translate("未找到 systemd 传递的 socket"))
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, errors.New(locale.Translate("未找到 systemd 传递的 socket"))
+	}
+
+	// 防止子进程再次使用这些值
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, n)
+	for fd := systemdFirstFD; fd < systemdFirstFD+n; fd++ {
+		file := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		l, err := net.FileListener(file)
+		file.Close() // FileListener 会复制一份文件描述符
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+
+	return listeners, nil
+}
+
+// 获取超时时间的配置，未经过 sanitize 的配置（比如测试中）使用默认值。
+func (a *app) timeouts() *timeouts {
+	if a.conf.Timeouts != nil {
+		return a.conf.Timeouts
+	}
+
+	t := &timeouts{}
+	t.sanitize()
+	return t
+}
+
+// 声明 http.Server，并设置各个超时时间。
+//
+// 声明的服务会被记录下来，在退出时由 shutdown 统一关闭。
+func (a *app) newServer(h http.Handler, tlsConf *tls.Config) *http.Server {
+	t := a.timeouts()
+
+	srv := &http.Server{
+		Handler:           h,
+		TLSConfig:         tlsConf,
+		ReadHeaderTimeout: t.ReadHeader,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       t.Idle,
+	}
+
+	a.serversLocker.Lock()
+	a.servers = append(a.servers, srv)
+	a.serversLocker.Unlock()
+
+	return srv
+}
+
+// 关闭所有通过 newServer 声明的服务。
+//
+// 会等待正在处理的请求完成，最长等待 timeouts.shutdown，超时返回错误。
+func (a *app) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeouts().Shutdown)
+	defer cancel()
+
+	a.serversLocker.Lock()
+	servers := a.servers
+	a.serversLocker.Unlock()
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			errs <- srv.Shutdown(ctx)
+		}(srv)
+	}
+
+	var err error
+	for range servers {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// 在所有的监听器上运行服务，任意一个出错即返回。
+// tlsConf 不为空时，运行 HTTPS 服务。
+func (a *app) serve(h http.Handler, tlsConf *tls.Config) error {
+	listeners, err := a.listeners()
+	if err != nil {
+		return err
+	}
+
+	srv := a.newServer(h, tlsConf)
+
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		logs.Info(locale.Translate("监听地址："), l.Addr().Network(), l.Addr().String())
+
+		go func(l net.Listener) {
+			if tlsConf != nil {
+				errs <- srv.ServeTLS(l, "", "")
+			} else {
+				errs <- srv.Serve(l)
+			}
+		}(l)
+	}
+
+	return <-errs
+}
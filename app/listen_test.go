@@ -0,0 +1,197 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert"
+)
+
+func TestCheckAddress(t *testing.T) {
+	a := assert.New(t)
+
+	a.Nil(checkAddress("port", ":80"))
+	a.Nil(checkAddress("port", "127.0.0.1:8080"))
+	a.Nil(checkAddress("port", "unix:/run/gitype.sock"))
+	a.Nil(checkAddress("port", systemdAddress))
+
+	a.Equal(checkAddress("port", "80").Field, "port")
+	a.Equal(checkAddress("listen[0]", "unix:").Field, "listen[0]")
+	a.Equal(checkAddress("port", "127.0.0.1:").Field, "port")
+}
+
+func TestConfig_sanitizeListen(t *testing.T) {
+	a := assert.New(t)
+
+	// systemd 只能指定一次
+	conf := &config{Port: systemdAddress, Listen: []string{":8080", systemdAddress}}
+	a.Equal(conf.sanitize().Field, "listen[1]")
+
+	// 非 TCP 地址时，不能监听 80 端口
+	conf = &config{HTTPS: true, Port: systemdAddress, HTTPState: httpStateDefault}
+	a.Equal(conf.sanitize().Field, "httpState")
+
+	// 非 TCP 地址时，httpState 默认为 disable
+	conf = &config{HTTPS: true, Port: "unix:/run/gitype.sock"}
+	a.Equal(conf.sanitize().Field, "certFile")
+	a.Equal(conf.HTTPState, httpStateDisable)
+}
+
+func TestParseSocketMode(t *testing.T) {
+	a := assert.New(t)
+
+	mode, err := parseSocketMode("")
+	a.Nil(err).Equal(mode, os.FileMode(defaultSocketMode))
+
+	mode, err = parseSocketMode("0666")
+	a.Nil(err).Equal(mode, os.FileMode(0666))
+
+	_, err = parseSocketMode("0999")
+	a.NotNil(err)
+
+	_, err = parseSocketMode("7777")
+	a.NotNil(err)
+}
+
+func TestSystemdListeners(t *testing.T) {
+	a := assert.New(t)
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	os.Setenv("LISTEN_FDS", "1")
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+
+	ls, err := systemdListeners()
+	a.Error(err).Nil(ls)
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "0")
+	ls, err = systemdListeners()
+	a.Error(err).Nil(ls)
+}
+
+func TestApp_serve(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "gitype-listen")
+	a.NotError(err)
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "gitype.sock")
+	a.NotError(ioutil.WriteFile(sock, nil, 0600)) // 普通文件不会被删除
+
+	app := newTestApp(&config{Port: unixPrefix + sock, socketMode: 0600})
+	_, err = app.listeners()
+	a.Error(err)
+
+	// 残留的 socket 文件会被删除
+	a.NotError(os.Remove(sock))
+	l, err := net.Listen("unix", sock)
+	a.NotError(err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	app.conf.Listen = []string{"127.0.0.1:0"}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	go app.serve(h, nil)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", sock)
+			},
+		},
+	}
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ { // 等待服务启动
+		if resp, err = client.Get("http://localhost/"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	a.NotError(err).Equal(resp.StatusCode, http.StatusAccepted)
+	resp.Body.Close()
+
+	stat, err := os.Stat(sock)
+	a.NotError(err)
+	a.Equal(stat.Mode().Perm(), os.FileMode(0600))
+}
+
+func TestApp_newServer(t *testing.T) {
+	a := assert.New(t)
+
+	// 未经过 sanitize 的配置
+	app := newTestApp(&config{})
+	srv := app.newServer(http.NotFoundHandler(), nil)
+	a.Equal(srv.ReadHeaderTimeout, defaultReadHeaderTimeout).
+		Equal(srv.ReadTimeout, defaultReadTimeout).
+		Equal(srv.WriteTimeout, defaultWriteTimeout).
+		Equal(srv.IdleTimeout, defaultIdleTimeout)
+
+	app = newTestApp(&config{Timeouts: &timeouts{ReadHeader: time.Second, Read: 2 * time.Second, Write: 3 * time.Second, Idle: 4 * time.Second}})
+	srv = app.newServer(http.NotFoundHandler(), nil)
+	a.Equal(srv.ReadHeaderTimeout, time.Second).
+		Equal(srv.ReadTimeout, 2*time.Second).
+		Equal(srv.WriteTimeout, 3*time.Second).
+		Equal(srv.IdleTimeout, 4*time.Second)
+}
+
+func TestApp_shutdown(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "gitype-shutdown")
+	a.NotError(err)
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "gitype.sock")
+	app := newTestApp(&config{Port: unixPrefix + sock, socketMode: 0600})
+
+	started := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	errs := make(chan error, 1)
+	go func() { errs <- app.serve(h, nil) }()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", sock)
+			},
+		},
+	}
+
+	resps := make(chan *http.Response, 1)
+	go func() {
+		for i := 0; i < 50; i++ { // 等待服务启动
+			if resp, err := client.Get("http://localhost/"); err == nil {
+				resps <- resp
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		resps <- nil
+	}()
+
+	// 正在处理的请求会在关闭服务之前完成
+	<-started
+	a.NotError(app.shutdown())
+	resp := <-resps
+	a.NotNil(resp).Equal(resp.StatusCode, http.StatusAccepted)
+	resp.Body.Close()
+	a.Equal(<-errs, http.ErrServerClosed)
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/caixw/gitype/locale"
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/logs"
+)
+
+// 维护页面中 Retry-After 报头的值
+const maintenanceRetryAfter = 60 * time.Second
+
+// 未指定 conf/maintenance.html 时，使用的维护页面
+const defaultMaintenance = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8" />
+<title>%s</title>
+</head>
+<body>
+<h1>%s</h1>
+<p>%s</p>
+</body>
+</html>
+`
+
+// 在数据未加载成功之前，除程序级别的路由之外，都显示维护页面。
+//
+// 数据一旦加载成功，之后的加载失败都会继续使用旧的数据，不会再显示维护页面。
+func (a *app) buildMaintenance(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.getClient() != nil || a.isAppRoute(r.URL.Path) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		a.maintenance(w)
+	})
+}
+
+func (a *app) isAppRoute(path string) bool {
+	for _, pattern := range a.patterns {
+		if pattern == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 输出维护页面，优先使用 conf/maintenance.html 的内容。
+func (a *app) maintenance(w http.ResponseWriter) {
+	content, err := ioutil.ReadFile(a.path.MaintenanceFile)
+	if err != nil {
+		title := locale.Translate("网站维护中")
+		content = []byte(fmt.Sprintf(defaultMaintenance, vars.Name, title, locale.Translate("数据正在加载，请稍后再访问。")))
+	}
+
+	w.Header().Set("Content-Type", "text/html;charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Retry-After", strconv.Itoa(int(maintenanceRetryAfter.Seconds())))
+	w.WriteHeader(http.StatusServiceUnavailable)
+	if _, err = w.Write(content); err != nil {
+		logs.Error(err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/caixw/gitype/metrics"
+)
+
+// 存活检测、就绪检测和运行指标的地址，地址值固定，不能修改。
+const (
+	healthzURL = "/healthz"
+	readyzURL  = "/readyz"
+	metricsURL = "/metrics"
+)
+
+// 初始化存活检测和就绪检测，以及启用时的运行指标
+func (a *app) initProbes() error {
+	if err := a.handleFunc(healthzURL, a.getHealthz, http.MethodGet); err != nil {
+		return err
+	}
+
+	if err := a.handleFunc(readyzURL, a.getReadyz, http.MethodGet); err != nil {
+		return err
+	}
+
+	if !a.conf.Metrics {
+		return nil
+	}
+
+	metrics.Posts.SetFunc(func() float64 {
+		if c := a.getClient(); c != nil {
+			return float64(len(c.Data().Posts))
+		}
+		return 0
+	})
+
+	return a.handleFunc(metricsURL, metrics.Handler, http.MethodGet)
+}
+
+// 存活检测，只要程序还能响应请求，就返回 200。
+func (a *app) getHealthz(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK)
+}
+
+// 就绪检测，数据加载成功之后才返回 200，否则返回 503。
+//
+// 数据加载成功之后，即使之后的加载失败，依然使用旧的数据提供服务，所以依然是就绪状态。
+func (a *app) getReadyz(w http.ResponseWriter, r *http.Request) {
+	if a.getClient() == nil {
+		writeProbe(w, http.StatusServiceUnavailable)
+		return
+	}
+
+	writeProbe(w, http.StatusOK)
+}
+
+func writeProbe(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "text/plain;charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	w.Write([]byte(http.StatusText(code)))
+}
@@ -0,0 +1,40 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/issue9/assert"
+)
+
+func TestApp_initProbes(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&config{Metrics: true})
+	a.NotError(app.initProbes())
+
+	get := func(url string, code int) string {
+		w := httptest.NewRecorder()
+		app.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		a.Equal(w.Code, code)
+		return w.Body.String()
+	}
+
+	get(healthzURL, http.StatusOK)
+	get(readyzURL, http.StatusServiceUnavailable)
+
+	a.NotError(app.reload())
+	get(healthzURL, http.StatusOK)
+	get(readyzURL, http.StatusOK)
+
+	get("/index.html", http.StatusOK)
+	body := get(metricsURL, http.StatusOK)
+	a.True(strings.Contains(body, "gitype_posts 3\n"))
+	a.True(strings.Contains(body, `gitype_reloads_total{result="success"}`))
+	a.True(strings.Contains(body, `gitype_http_requests_total{route="/index.html",code="200"}`))
+}
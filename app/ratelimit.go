@@ -0,0 +1,228 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
+	"github.com/issue9/logs"
+)
+
+// 超过此时间未访问的令牌桶会被清除
+const bucketIdle = 10 * time.Minute
+
+// 访问限制的相关配置
+type rateLimit struct {
+	Rate  float64 `yaml:"rate"`  // 每个 IP 每秒可以发起的请求数量
+	Burst int     `yaml:"burst"` // 每个 IP 最多可以连续发起的请求数量
+
+	// 搜索页和 webhook 单独计算，为空表示与其它页面共用 rate 和 burst。
+	// 搜索需要遍历所有文章，webhook 会执行 git 命令，都应该比普通页面更严格。
+	Search  *bucketConfig `yaml:"search,omitempty"`
+	Webhook *bucketConfig `yaml:"webhook,omitempty"`
+
+	MaxBodySize  int64    `yaml:"maxBodySize,omitempty"`  // 请求内容的最大字节数，0 表示不限制
+	MaxURLLength int      `yaml:"maxURLLength,omitempty"` // 请求地址的最大长度，0 表示不限制
+	Deny         []string `yaml:"deny,omitempty"`         // 禁止访问的 IP 或 CIDR
+	deny         ipNets
+}
+
+type bucketConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// 一组令牌桶，每个 IP 一个。
+type limiter struct {
+	rate  float64
+	burst float64
+
+	locker  sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time // 最后一次清除过期令牌桶的时间
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucketConfig) sanitize(field string) *helper.FieldError {
+	switch {
+	case b.Rate <= 0:
+		return &helper.FieldError{Field: field + ".rate", Message: "必须大于 0"}
+	case b.Burst <= 0:
+		return &helper.FieldError{Field: field + ".burst", Message: "必须大于 0"}
+	}
+
+	return nil
+}
+
+func (l *rateLimit) sanitize() *helper.FieldError {
+	if err := (&bucketConfig{Rate: l.Rate, Burst: l.Burst}).sanitize("rateLimit"); err != nil {
+		return err
+	}
+
+	if l.Search != nil {
+		if err := l.Search.sanitize("rateLimit.search"); err != nil {
+			return err
+		}
+	}
+
+	if l.Webhook != nil {
+		if err := l.Webhook.sanitize("rateLimit.webhook"); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case l.MaxBodySize < 0:
+		return &helper.FieldError{Field: "rateLimit.maxBodySize", Message: "不能小于 0"}
+	case l.MaxURLLength < 0:
+		return &helper.FieldError{Field: "rateLimit.maxURLLength", Message: "不能小于 0"}
+	}
+
+	deny, err := parseIPNets("rateLimit.deny", l.Deny)
+	if err != nil {
+		return err
+	}
+	l.deny = deny
+
+	return nil
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	return &limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket, 100),
+		swept:   time.Now(),
+	}
+}
+
+// 从 ip 对应的令牌桶中取出一个令牌，
+// 没有令牌时返回 false，以及需要等待的时间。
+func (l *limiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	l.sweep(now)
+
+	b, found := l.buckets[ip]
+	if !found {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[ip] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// 清除长时间未访问的令牌桶，防止占用过多的内存。
+// 这些令牌桶早已经被填满，删除与否对结果没有影响。
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < bucketIdle {
+		return
+	}
+
+	for ip, b := range l.buckets {
+		if now.Sub(b.last) > bucketIdle {
+			delete(l.buckets, ip)
+		}
+	}
+	l.swept = now
+}
+
+// 对请求进行访问限制，未配置 rateLimit 时，直接返回 h。
+//
+// 除 webhook 之外，由程序本身注册的路由不受频率限制，但依然受 deny 等其它规则的限制。
+func (a *app) buildRateLimit(h http.Handler) http.Handler {
+	conf := a.conf.RateLimit
+	if conf == nil {
+		return h
+	}
+
+	def := newLimiter(conf.Rate, conf.Burst)
+	search, webhook := def, def
+	if conf.Search != nil {
+		search = newLimiter(conf.Search.Rate, conf.Search.Burst)
+	}
+	if conf.Webhook != nil {
+		webhook = newLimiter(conf.Webhook.Rate, conf.Webhook.Burst)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := remoteIP(r)
+		if conf.deny.contains(ip) {
+			a.renderError(w, r, http.StatusForbidden)
+			return
+		}
+
+		if conf.MaxURLLength > 0 && len(r.RequestURI) > conf.MaxURLLength {
+			a.renderError(w, r, http.StatusRequestURITooLong)
+			return
+		}
+
+		if conf.MaxBodySize > 0 {
+			if r.ContentLength > conf.MaxBodySize {
+				a.renderError(w, r, http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, conf.MaxBodySize)
+		}
+
+		l := def
+		switch path := r.URL.Path; {
+		case path == a.conf.Webhook.URL:
+			l = webhook
+		case path == a.searchURL():
+			l = search
+		case a.isAppRoute(path): // 探针、metrics 和管理接口等不作频率限制
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if ok, wait := l.allow(ip, time.Now()); !ok {
+			logs.Debugf(locale.Translate("%s 的请求过于频繁：%s"), ip, r.URL)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			a.renderError(w, r, http.StatusTooManyRequests)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// 搜索页的地址，由数据决定，数据未加载时返回空值。
+func (a *app) searchURL() string {
+	if c := a.getClient(); c != nil {
+		return c.Data().URLs.SearchURL("", 1)
+	}
+	return ""
+}
+
+// 输出错误页面，数据加载成功时使用主题中的错误模板。
+func (a *app) renderError(w http.ResponseWriter, r *http.Request, code int) {
+	if c := a.getClient(); c != nil {
+		c.RenderError(w, r, code)
+		return
+	}
+
+	helper.StatusError(w, code)
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert"
+)
+
+func TestRateLimit_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	l := &rateLimit{Rate: 1, Burst: 5}
+	a.NotError(l.sanitize())
+
+	l.Search = &bucketConfig{Rate: 0, Burst: 1}
+	a.Equal(l.sanitize().Field, "rateLimit.search.rate")
+
+	l.Search.Rate = 0.5
+	l.Deny = []string{"10.0.0.0/8", "abc"}
+	a.Equal(l.sanitize().Field, "rateLimit.deny[1]")
+
+	l.Deny = l.Deny[:1]
+	a.NotError(l.sanitize())
+	a.True(l.deny.contains("10.1.1.1"))
+}
+
+func TestLimiter_allow(t *testing.T) {
+	a := assert.New(t)
+	l := newLimiter(2, 3)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.allow("1.1.1.1", now)
+		a.True(ok)
+	}
+	ok, wait := l.allow("1.1.1.1", now)
+	a.False(ok).Equal(wait, 500*time.Millisecond)
+
+	// 其它 IP 不受影响
+	ok, _ = l.allow("2.2.2.2", now)
+	a.True(ok)
+
+	// 0.5 秒之后恢复一个令牌
+	ok, _ = l.allow("1.1.1.1", now.Add(500*time.Millisecond))
+	a.True(ok)
+
+	// 长时间未访问的令牌桶被清除
+	l.allow("3.3.3.3", now.Add(2*bucketIdle))
+	a.Equal(len(l.buckets), 1)
+}
+
+func TestApp_buildRateLimit(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&config{
+		Webhook: &webhook{URL: "/webhook"},
+		RateLimit: &rateLimit{
+			Rate:         100,
+			Burst:        100,
+			Search:       &bucketConfig{Rate: 0.01, Burst: 1},
+			MaxBodySize:  10,
+			MaxURLLength: 30,
+			Deny:         []string{"10.0.0.0/8"},
+		},
+	})
+	a.NotError(app.conf.RateLimit.sanitize())
+	a.NotError(app.reload())
+	h := app.buildRateLimit(app.mux)
+
+	serve := func(method, url, ip, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, url, strings.NewReader(body))
+		r.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	a.Equal(serve(http.MethodGet, "/index.html", "10.1.1.1", "").Code, http.StatusForbidden)
+	a.Equal(serve(http.MethodGet, "/index.html?q="+strings.Repeat("x", 30), "1.1.1.1", "").Code, http.StatusRequestURITooLong)
+	a.Equal(serve(http.MethodPost, "/webhook", "1.1.1.1", strings.Repeat("x", 11)).Code, http.StatusRequestEntityTooLarge)
+
+	// 搜索单独计算
+	a.Equal(serve(http.MethodGet, "/search.html?q=abc", "1.1.1.1", "").Code, http.StatusOK)
+	w := serve(http.MethodGet, "/search.html?q=abc", "1.1.1.1", "")
+	a.Equal(w.Code, http.StatusTooManyRequests)
+	a.Equal(w.Header().Get("Retry-After"), "100")
+	a.True(strings.Contains(w.Body.String(), "too many requests")) // 主题中的 429.html
+	a.Equal(serve(http.MethodGet, "/index.html", "1.1.1.1", "").Code, http.StatusOK)
+
+	// 程序注册的路由不受频率限制，但依然受 deny 的限制
+	a.NotError(app.handleFunc(healthzURL, app.getHealthz, http.MethodGet))
+	for i := 0; i < 200; i++ {
+		a.Equal(serve(http.MethodGet, healthzURL, "2.2.2.2", "").Code, http.StatusOK)
+	}
+	a.Equal(serve(http.MethodGet, healthzURL, "10.1.1.1", "").Code, http.StatusForbidden)
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/caixw/gitype/helper"
+)
+
+// IP 地址的列表，比如受信任的代理服务器和禁止访问的地址
+type ipNets []*net.IPNet
+
+// 将 IP 或是 CIDR 格式的字符串转换成 ipNets，field 为出错时的字段名。
+func parseIPNets(field string, addrs []string) (ipNets, *helper.FieldError) {
+	ps := make(ipNets, 0, len(addrs))
+	for index, addr := range addrs {
+		if !strings.Contains(addr, "/") {
+			if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+				addr += "/32"
+			} else {
+				addr += "/128"
+			}
+		}
+
+		_, ipnet, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, &helper.FieldError{Field: field + "[" + strconv.Itoa(index) + "]", Message: "无效的 IP 或 CIDR"}
+		}
+		ps = append(ps, ipnet)
+	}
+
+	return ps, nil
+}
+
+func (ps ipNets) contains(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, p := range ps {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取客户端的真实 IP。
+//
+// 只有在直接连接的地址为受信任的代理时，才会从 X-Forwarded-For 中从右往左查找，
+// 第一个非受信任的地址即为客户端的地址，否则客户端可以通过伪造报头冒充任意 IP。
+//
+// 通过 unix socket 连接的对端没有 IP 地址（r.RemoteAddr 为 @），
+// 且只有本机的程序才能连接，所以始终被视为受信任的代理。
+func (ps ipNets) clientIP(r *http.Request) string {
+	ip := remoteIP(r)
+	if !isUnixPeer(ip) && !ps.contains(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if len(addr) == 0 {
+			continue
+		}
+
+		ip = addr
+		if !ps.contains(addr) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// 直接连接的对端地址，不包含端口
+func remoteIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return ip
+}
+
+// 是否为通过 unix socket 连接的对端
+func isUnixPeer(ip string) bool {
+	return net.ParseIP(ip) == nil
+}
+
+// 将 r.RemoteAddr 替换成客户端的真实 IP，之后的中间件和路由都可以直接使用 r.RemoteAddr。
+//
+// 监听 unix socket 时，即使未指定受信任的代理，也需要从 X-Forwarded-For 中获取客户端 IP。
+func (a *app) buildRealIP(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := a.conf.proxies.clientIP(r); ip != remoteIP(r) {
+			r.RemoteAddr = net.JoinHostPort(ip, "0")
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/issue9/assert"
+)
+
+func TestParseIPNets(t *testing.T) {
+	a := assert.New(t)
+
+	ps, err := parseIPNets("trustedProxies", []string{"127.0.0.1", "10.0.0.0/8", "::1"})
+	a.Nil(err).Equal(len(ps), 3)
+	a.True(ps.contains("127.0.0.1"))
+	a.True(ps.contains("10.1.2.3"))
+	a.True(ps.contains("::1"))
+	a.False(ps.contains("127.0.0.2"))
+	a.False(ps.contains("invalid"))
+
+	ps, err = parseIPNets("trustedProxies", []string{"10.0.0.0/8", "invalid"})
+	a.NotNil(err).Nil(ps)
+	a.Equal(err.Field, "trustedProxies[1]")
+}
+
+func TestIPNets_clientIP(t *testing.T) {
+	a := assert.New(t)
+	ps, err := parseIPNets("trustedProxies", []string{"127.0.0.1", "10.0.0.0/8"})
+	a.Nil(err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "1.1.1.1:1234"
+	r.Header.Set("X-Forwarded-For", "2.2.2.2")
+	a.Equal(ps.clientIP(r), "1.1.1.1") // 非受信任的代理，忽略 X-Forwarded-For
+
+	r.RemoteAddr = "127.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "3.3.3.3, 2.2.2.2, 10.0.0.1")
+	a.Equal(ps.clientIP(r), "2.2.2.2")
+
+	r.Header.Set("X-Forwarded-For", "10.0.0.2, 10.0.0.1")
+	a.Equal(ps.clientIP(r), "10.0.0.2")
+
+	r.Header.Del("X-Forwarded-For")
+	a.Equal(ps.clientIP(r), "127.0.0.1")
+
+	// 未指定受信任的代理
+	r.Header.Set("X-Forwarded-For", "2.2.2.2")
+	a.Equal(ipNets(nil).clientIP(r), "127.0.0.1")
+
+	// unix socket 的对端始终是受信任的代理
+	r.RemoteAddr = "@"
+	a.Equal(ipNets(nil).clientIP(r), "2.2.2.2")
+	r.Header.Del("X-Forwarded-For")
+	a.Equal(ipNets(nil).clientIP(r), "@")
+}
+
+func TestApp_buildRealIP(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&config{})
+
+	var addr string
+	h := app.buildRealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr = r.RemoteAddr
+	}))
+
+	// 未指定受信任的代理，TCP 连接保持原样
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "1.1.1.1:1234"
+	r.Header.Set("X-Forwarded-For", "2.2.2.2")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	a.Equal(addr, "1.1.1.1:1234")
+
+	// 通过 unix socket 连接
+	r.RemoteAddr = "@"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	a.Equal(addr, "2.2.2.2:0")
+}
@@ -0,0 +1,150 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caixw/gitype/helper"
+)
+
+// CSP 中 nonce 的占位符，每次请求都会被替换成 'nonce-xxx' 的形式。
+const cspNoncePlaceholder = "%nonce%"
+
+// referrerPolicy 的默认值
+const defaultReferrerPolicy = "strict-origin-when-cross-origin"
+
+// 安全相关的报头
+type security struct {
+	HSTS              *hsts   `yaml:"hsts,omitempty"`              // 仅在 HTTPS 下输出
+	CSP               *string `yaml:"csp,omitempty"`               // Content-Security-Policy，可以包含 %nonce% 占位符
+	ReferrerPolicy    *string `yaml:"referrerPolicy,omitempty"`    // 默认为 strict-origin-when-cross-origin
+	PermissionsPolicy *string `yaml:"permissionsPolicy,omitempty"` // Permissions-Policy
+
+	// 针对部分路由的设置，未指定的字段使用上面的值，
+	// 指定为空字符串表示不输出该报头。
+	Routes []*securityRoute `yaml:"routes,omitempty"`
+}
+
+type hsts struct {
+	MaxAge            time.Duration `yaml:"maxAge"`
+	IncludeSubDomains bool          `yaml:"includeSubDomains,omitempty"`
+	Preload           bool          `yaml:"preload,omitempty"`
+}
+
+type securityRoute struct {
+	Prefix            string  `yaml:"prefix"` // 路由的前缀，比如 /themes/，多个匹配时，以最长的为准
+	CSP               *string `yaml:"csp,omitempty"`
+	ReferrerPolicy    *string `yaml:"referrerPolicy,omitempty"`
+	PermissionsPolicy *string `yaml:"permissionsPolicy,omitempty"`
+}
+
+func (s *security) sanitize() *helper.FieldError {
+	if s.ReferrerPolicy == nil {
+		policy := defaultReferrerPolicy
+		s.ReferrerPolicy = &policy
+	}
+
+	if s.HSTS != nil && s.HSTS.MaxAge <= 0 {
+		return &helper.FieldError{Field: "security.hsts.maxAge", Message: "必须大于 0"}
+	}
+
+	for index, route := range s.Routes {
+		if len(route.Prefix) == 0 || route.Prefix[0] != '/' {
+			return &helper.FieldError{Field: "security.routes[" + strconv.Itoa(index) + "].prefix", Message: "不能为空且只能以 / 开头"}
+		}
+	}
+
+	return nil
+}
+
+// 获取与 path 匹配的路由设置，没有则返回 nil。
+func (s *security) route(path string) *securityRoute {
+	var matched *securityRoute
+	for _, route := range s.Routes {
+		if strings.HasPrefix(path, route.Prefix) &&
+			(matched == nil || len(route.Prefix) > len(matched.Prefix)) {
+			matched = route
+		}
+	}
+
+	return matched
+}
+
+func (h *hsts) String() string {
+	v := "max-age=" + strconv.FormatInt(int64(h.MaxAge.Seconds()), 10)
+	if h.IncludeSubDomains {
+		v += "; includeSubDomains"
+	}
+	if h.Preload {
+		v += "; preload"
+	}
+	return v
+}
+
+// 输出安全相关的报头，未配置 security 时，直接返回 h。
+//
+// 需要在 buildHeader 之外，这样 headers 中的同名报头可以覆盖这里的值。
+func (a *app) buildSecurity(h http.Handler) http.Handler {
+	conf := a.conf.Security
+	if conf == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+
+		if conf.HSTS != nil && a.conf.HTTPS && r.TLS != nil {
+			header.Set("Strict-Transport-Security", conf.HSTS.String())
+		}
+
+		csp, referrer, permissions := conf.CSP, conf.ReferrerPolicy, conf.PermissionsPolicy
+		if route := conf.route(r.URL.Path); route != nil {
+			if route.CSP != nil {
+				csp = route.CSP
+			}
+			if route.ReferrerPolicy != nil {
+				referrer = route.ReferrerPolicy
+			}
+			if route.PermissionsPolicy != nil {
+				permissions = route.PermissionsPolicy
+			}
+		}
+
+		if csp != nil && len(*csp) > 0 {
+			policy := *csp
+			if strings.Contains(policy, cspNoncePlaceholder) {
+				nonce := newNonce()
+				policy = strings.Replace(policy, cspNoncePlaceholder, "'nonce-"+nonce+"'", -1)
+				r = helper.WithNonce(r, nonce)
+			}
+			header.Set("Content-Security-Policy", policy)
+		}
+
+		if referrer != nil && len(*referrer) > 0 {
+			header.Set("Referrer-Policy", *referrer)
+		}
+
+		if permissions != nil && len(*permissions) > 0 {
+			header.Set("Permissions-Policy", *permissions)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func newNonce() string {
+	bs := make([]byte, 16)
+	if _, err := rand.Read(bs); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(bs)
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/issue9/assert"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestSecurity_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	s := &security{}
+	a.NotError(s.sanitize())
+	a.Equal(*s.ReferrerPolicy, defaultReferrerPolicy)
+
+	s.HSTS = &hsts{}
+	a.Equal(s.sanitize().Field, "security.hsts.maxAge")
+
+	s.HSTS.MaxAge = time.Hour
+	s.Routes = []*securityRoute{{Prefix: "themes/"}}
+	a.Equal(s.sanitize().Field, "security.routes[0].prefix")
+}
+
+func TestSecurity_route(t *testing.T) {
+	a := assert.New(t)
+
+	s := &security{
+		Routes: []*securityRoute{
+			{Prefix: "/themes/"},
+			{Prefix: "/themes/t1/"},
+			{Prefix: "/posts/"},
+		},
+	}
+	a.Equal(s.route("/themes/t1/style.css"), s.Routes[1])
+	a.Equal(s.route("/themes/t2/style.css"), s.Routes[0])
+	a.Nil(s.route("/index.html"))
+}
+
+func TestHSTS_String(t *testing.T) {
+	a := assert.New(t)
+
+	h := &hsts{MaxAge: time.Hour}
+	a.Equal(h.String(), "max-age=3600")
+
+	h.IncludeSubDomains = true
+	h.Preload = true
+	a.Equal(h.String(), "max-age=3600; includeSubDomains; preload")
+}
+
+func TestApp_buildSecurity(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&config{
+		HTTPS: true,
+		Security: &security{
+			HSTS:              &hsts{MaxAge: time.Hour},
+			CSP:               strPtr("script-src 'self' %nonce%"),
+			PermissionsPolicy: strPtr("geolocation=()"),
+			Routes: []*securityRoute{
+				{Prefix: "/themes/", CSP: strPtr(""), ReferrerPolicy: strPtr("no-referrer")},
+			},
+		},
+	})
+	a.NotError(app.conf.Security.sanitize())
+
+	var nonce string
+	h := app.buildSecurity(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = helper.Nonce(r)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/index.html", nil))
+	header := w.Header()
+	a.NotEmpty(nonce)
+	a.Equal(header.Get("Content-Security-Policy"), "script-src 'self' 'nonce-"+nonce+"'")
+	a.Equal(header.Get("Referrer-Policy"), defaultReferrerPolicy)
+	a.Equal(header.Get("Permissions-Policy"), "geolocation=()")
+	a.Equal(header.Get("X-Content-Type-Options"), "nosniff")
+	a.Empty(header.Get("Strict-Transport-Security")) // 非 HTTPS 请求
+
+	// 每次请求的 nonce 都不同
+	prev := nonce
+	r := httptest.NewRequest(http.MethodGet, "/index.html", nil)
+	r.TLS = &tls.ConnectionState{}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	a.NotEqual(nonce, prev)
+	a.Equal(w.Header().Get("Strict-Transport-Security"), "max-age=3600")
+
+	// 针对路由的设置
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/themes/t1/style.css", nil))
+	header = w.Header()
+	a.Empty(nonce)
+	a.Empty(header.Get("Content-Security-Policy"))
+	a.Equal(header.Get("Referrer-Policy"), "no-referrer")
+	a.Equal(header.Get("Permissions-Policy"), "geolocation=()")
+}
@@ -0,0 +1,259 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
+	"github.com/issue9/logs"
+)
+
+// 检测证书文件是否有变化的最小时间间隔
+const certCheckInterval = time.Minute
+
+// Alt-Svc 报头中 ma 的值，即客户端记住 HTTP/3 可用的时间，单位为秒
+const altSvcMaxAge = 86400
+
+// HTTP/2 要求必须支持的加密套件，自定义 cipherSuites 时至少需要包含其中之一。
+var http2CipherSuites = []uint16{
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+}
+
+// tls.minVersion 的可选值
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// HTTPS 的相关配置，仅在 https 为 true 时有效。
+type tlsConfig struct {
+	MinVersion   string   `yaml:"minVersion,omitempty"`   // 最低的 TLS 版本，默认为 1.2
+	CipherSuites []string `yaml:"cipherSuites,omitempty"` // 允许的加密套件，为空表示使用 Go 的默认值
+
+	// OCSP 响应文件，DER 格式，可以由 openssl ocsp 等工具定时获取，
+	// 与证书一样，文件有变化时会自动重新加载。
+	OCSPFile string `yaml:"ocspFile,omitempty"`
+
+	// 是否同时启用 HTTP/3，需要在编译时指定 http3 标签。
+	HTTP3 bool `yaml:"http3,omitempty"`
+
+	minVersion   uint16
+	cipherSuites []uint16
+}
+
+// 证书的加载器，证书文件有变化时，在下一次握手时自动重新加载，不需要重启程序。
+type certLoader struct {
+	certFile string
+	keyFile  string
+	ocspFile string
+
+	locker  sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time // 已加载的文件中最新的修改时间
+	checked time.Time // 最后一次检测文件的时间
+}
+
+func (conf *tlsConfig) sanitize() *helper.FieldError {
+	if len(conf.MinVersion) == 0 {
+		conf.MinVersion = "1.2"
+	}
+
+	version, found := tlsVersions[conf.MinVersion]
+	if !found {
+		return &helper.FieldError{Field: "tls.minVersion", Message: "无效的取值"}
+	}
+	conf.minVersion = version
+
+	suites := make(map[string]uint16, 50)
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	insecure := make(map[string]bool, 20)
+	for _, s := range tls.InsecureCipherSuites() {
+		insecure[s.Name] = true
+	}
+
+	conf.cipherSuites = make([]uint16, 0, len(conf.CipherSuites))
+	http2 := false
+	for index, name := range conf.CipherSuites {
+		field := "tls.cipherSuites[" + strconv.Itoa(index) + "]"
+		if insecure[name] {
+			return &helper.FieldError{Field: field, Message: "不安全的加密套件"}
+		}
+
+		id, found := suites[name]
+		if !found {
+			return &helper.FieldError{Field: field, Message: "无效的取值"}
+		}
+		conf.cipherSuites = append(conf.cipherSuites, id)
+		http2 = http2 || inUint16s(http2CipherSuites, id)
+	}
+
+	// TLS 1.3 的加密套件不可配置，不受 cipherSuites 的影响。
+	if len(conf.cipherSuites) > 0 && !http2 && conf.minVersion < tls.VersionTLS13 {
+		return &helper.FieldError{Field: "tls.cipherSuites", Message: "必须包含 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 或是 TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256，否则无法使用 HTTP/2"}
+	}
+
+	if conf.HTTP3 && !http3Supported {
+		return &helper.FieldError{Field: "tls.http3", Message: "未启用 HTTP/3，需要使用 http3 标签重新编译"}
+	}
+
+	return nil
+}
+
+func inUint16s(list []uint16, val uint16) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+func newCertLoader(certFile, keyFile, ocspFile string) (*certLoader, error) {
+	l := &certLoader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		ocspFile: ocspFile,
+	}
+
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// 加载证书以及 OCSP 响应
+func (l *certLoader) load() error {
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return err
+	}
+
+	if len(l.ocspFile) > 0 {
+		if cert.OCSPStaple, err = ioutil.ReadFile(l.ocspFile); err != nil {
+			return err
+		}
+	}
+
+	l.cert = &cert
+	l.modTime = l.latestModTime()
+	l.checked = time.Now()
+	return nil
+}
+
+// 获取所有文件中最新的修改时间
+func (l *certLoader) latestModTime() time.Time {
+	var latest time.Time
+	for _, file := range []string{l.certFile, l.keyFile, l.ocspFile} {
+		if len(file) == 0 {
+			continue
+		}
+
+		stat, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if stat.ModTime().After(latest) {
+			latest = stat.ModTime()
+		}
+	}
+
+	return latest
+}
+
+// 用于 tls.Config.GetCertificate，重新加载失败时，继续使用旧的证书。
+func (l *certLoader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	if time.Now().Sub(l.checked) < certCheckInterval {
+		return l.cert, nil
+	}
+
+	l.checked = time.Now()
+	if l.latestModTime().After(l.modTime) {
+		if err := l.load(); err != nil {
+			logs.Error(err)
+		} else {
+			logs.Info(locale.Translate("重新加载了证书："), l.certFile)
+		}
+	}
+
+	return l.cert, nil
+}
+
+// 生成 HTTPS 服务所使用的 tls.Config
+func (a *app) buildTLSConfig() (*tls.Config, error) {
+	conf := a.conf.TLS
+	loader, err := newCertLoader(a.conf.CertFile, a.conf.KeyFile, conf.OCSPFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConf := &tls.Config{
+		MinVersion:     conf.minVersion,
+		GetCertificate: loader.getCertificate,
+	}
+	if len(conf.cipherSuites) > 0 {
+		tlsConf.CipherSuites = conf.cipherSuites
+	}
+
+	return tlsConf, nil
+}
+
+// 启用 HTTP/3 时，通过 Alt-Svc 报头告知客户端 HTTP/3 的端口。
+//
+// Alt-Svc 中只能包含端口，不能包含监听的 IP；HTTP/3 服务退出之后不再输出该报头。
+func (a *app) buildAltSvc(h http.Handler) http.Handler {
+	if !a.conf.TLS.HTTP3 {
+		return h
+	}
+
+	_, port, err := net.SplitHostPort(a.conf.Port)
+	if err != nil { // sanitize 已经保证了 port 为 TCP 地址，一般不会出错。
+		port = strings.TrimPrefix(a.conf.Port, ":")
+	}
+
+	altSvc := `h3=":` + port + `"; ma=` + strconv.Itoa(altSvcMaxAge)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&a.http3Stopped) == 0 {
+			w.Header().Set("Alt-Svc", altSvc)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// 运行 HTTPS 服务，以及启用时的 HTTP/3 服务
+func (a *app) serveTLS(h http.Handler) error {
+	tlsConf, err := a.buildTLSConfig()
+	if err != nil {
+		return err
+	}
+
+	if a.conf.TLS.HTTP3 {
+		go func() {
+			err := serveHTTP3(a.conf.Port, h, tlsConf.Clone())
+			atomic.StoreInt32(&a.http3Stopped, 1)
+			logs.Error(err)
+		}()
+	}
+
+	return a.serve(a.buildAltSvc(h), tlsConf)
+}
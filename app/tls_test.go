@@ -0,0 +1,156 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert"
+)
+
+// 在 dir 下生成自签名的证书 cert.pem 和 key.pem，name 为证书的 CommonName。
+func writeCert(a *assert.Assertion, dir, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	a.NotError(err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	a.NotError(err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	a.NotError(err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	a.NotError(ioutil.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0600))
+	a.NotError(ioutil.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0600))
+}
+
+func TestTLSConfig_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	conf := &tlsConfig{}
+	a.NotError(conf.sanitize())
+	a.Equal(conf.minVersion, tls.VersionTLS12)
+
+	conf.MinVersion = "1.4"
+	a.Equal(conf.sanitize().Field, "tls.minVersion")
+
+	conf.MinVersion = "1.3"
+	conf.CipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "abc"}
+	a.Equal(conf.sanitize().Field, "tls.cipherSuites[1]")
+
+	conf.CipherSuites = conf.CipherSuites[:1]
+	a.NotError(conf.sanitize())
+	a.Equal(conf.cipherSuites, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256})
+
+	// 不安全的加密套件
+	conf.CipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_RC4_128_SHA"}
+	a.Equal(conf.sanitize().Field, "tls.cipherSuites[1]")
+
+	// 缺少 HTTP/2 必须的加密套件，TLS 1.3 不受影响
+	conf.CipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}
+	a.NotError(conf.sanitize())
+	conf.MinVersion = "1.2"
+	a.Equal(conf.sanitize().Field, "tls.cipherSuites")
+	conf.CipherSuites = append(conf.CipherSuites, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256")
+	a.NotError(conf.sanitize())
+
+	conf.HTTP3 = true
+	if http3Supported {
+		a.NotError(conf.sanitize())
+	} else {
+		a.Equal(conf.sanitize().Field, "tls.http3")
+	}
+}
+
+func TestCertLoader(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "gitype-tls")
+	a.NotError(err)
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ocspFile := filepath.Join(dir, "ocsp.der")
+	writeCert(a, dir, "cert1")
+	a.NotError(ioutil.WriteFile(ocspFile, []byte("ocsp"), 0600))
+
+	l, err := newCertLoader(certFile, keyFile, ocspFile)
+	a.NotError(err)
+	cert, err := l.getCertificate(nil)
+	a.NotError(err)
+	a.Equal(cert.OCSPStaple, []byte("ocsp"))
+	old := cert
+
+	// 文件有变化，但未到检测时间
+	writeCert(a, dir, "cert2")
+	future := time.Now().Add(time.Hour)
+	a.NotError(os.Chtimes(certFile, future, future))
+	cert, err = l.getCertificate(nil)
+	a.NotError(err)
+	a.Equal(cert, old)
+
+	l.checked = time.Now().Add(-2 * certCheckInterval)
+	cert, err = l.getCertificate(nil)
+	a.NotError(err)
+	a.NotEqual(cert, old)
+
+	// 加载失败，继续使用旧的证书
+	old = cert
+	a.NotError(ioutil.WriteFile(keyFile, []byte("invalid"), 0600))
+	future = future.Add(time.Hour)
+	a.NotError(os.Chtimes(keyFile, future, future))
+	l.checked = time.Now().Add(-2 * certCheckInterval)
+	cert, err = l.getCertificate(nil)
+	a.NotError(err)
+	a.Equal(cert, old)
+}
+
+func TestApp_buildAltSvc(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&config{Port: ":443", TLS: &tlsConfig{}})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	app.buildAltSvc(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	a.Empty(w.Header().Get("Alt-Svc"))
+
+	app.conf.TLS.HTTP3 = true
+	w = httptest.NewRecorder()
+	app.buildAltSvc(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	a.Equal(w.Header().Get("Alt-Svc"), `h3=":443"; ma=86400`)
+
+	// 只包含端口
+	app.conf.Port = "127.0.0.1:8443"
+	w = httptest.NewRecorder()
+	h := app.buildAltSvc(next)
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	a.Equal(w.Header().Get("Alt-Svc"), `h3=":8443"; ma=86400`)
+
+	// HTTP/3 服务已经退出
+	app.http3Stopped = 1
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	a.Empty(w.Header().Get("Alt-Svc"))
+}
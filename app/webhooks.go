@@ -5,12 +5,18 @@
 package app
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
+	"github.com/caixw/gitype/metrics"
 	"github.com/issue9/logs"
 	"github.com/issue9/utils"
 )
@@ -25,34 +31,81 @@ func (w *logWriter) Write(bs []byte) (int, error) {
 
 // webhooks 的回调接口
 func (a *app) postWebhooks(w http.ResponseWriter, r *http.Request) {
-	if time.Now().Sub(a.client.Created()) < a.conf.Webhook.Frequency {
-		logs.Error("更新过于频繁，被中止！")
+	if time.Now().Sub(a.status.lastWebhook()) < a.conf.Webhook.Frequency {
+		logs.Error(locale.Translate("更新过于频繁，被中止！"))
+		metrics.Webhooks.Inc(metrics.ResultRejected)
 		helper.StatusError(w, http.StatusTooManyRequests)
 		return
 	}
 
-	var cmd *exec.Cmd
+	// 更新仓库、加载数据和回滚需要作为一个整体，不能与其它的加载操作交叉进行。
+	a.reloadLocker.Lock()
+	defer a.reloadLocker.Unlock()
+
+	// 同时输出到日志和管理后台
+	output := new(bytes.Buffer)
+
+	var err error
 	if utils.FileExists(a.path.DataDir) {
-		cmd = exec.Command("git", "pull")
-		cmd.Dir = a.path.DataDir
+		err = a.git(output, a.path.DataDir, "pull")
 	} else {
-		cmd = exec.Command("git", "clone", a.conf.Webhook.RepoURL, a.path.DataDir)
-		cmd.Dir = a.path.Root
+		err = a.git(output, a.path.Root, "clone", a.conf.Webhook.RepoURL, a.path.DataDir)
 	}
 
-	cmd.Stderr = (*logWriter)(logs.ERROR())
-	cmd.Stdout = (*logWriter)(logs.INFO())
-	if err := cmd.Run(); err != nil {
-		logs.Error(err)
-		helper.StatusError(w, http.StatusInternalServerError)
-		return
+	if err == nil {
+		if err = a.load(); err != nil && a.conf.Webhook.Rollback {
+			if e := a.rollback(output); e != nil {
+				logs.Error(e)
+			}
+		}
 	}
 
-	if err := a.reload(); err != nil {
+	a.status.webhooked(output.String(), err)
+	if err != nil {
 		logs.Error(err)
+		metrics.Webhooks.Inc(metrics.ResultFailure)
 		helper.StatusError(w, http.StatusInternalServerError)
 		return
 	}
 
+	metrics.Webhooks.Inc(metrics.ResultSuccess)
 	w.WriteHeader(http.StatusCreated)
 }
+
+// 在 dir 目录下执行 git 命令，输出内容同时写入日志和 output。
+func (a *app) git(output io.Writer, dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = io.MultiWriter((*logWriter)(logs.ERROR()), output)
+	cmd.Stdout = io.MultiWriter((*logWriter)(logs.INFO()), output)
+	return cmd.Run()
+}
+
+// 将数据仓库回滚到最后一次成功加载时的提交。
+//
+// 加载失败时，依然使用旧的数据，所以回滚之后不需要重新加载。
+func (a *app) rollback(output io.Writer) error {
+	commit := a.status.lastCommit()
+	if len(commit) == 0 {
+		return errors.New(locale.Translate("没有可以回滚的版本"))
+	}
+
+	if err := a.git(output, a.path.DataDir, "reset", "--hard", commit); err != nil {
+		return err
+	}
+
+	logs.Info(locale.Translate("数据仓库已回滚到："), commit)
+	return nil
+}
+
+// 获取数据仓库当前的提交
+func (a *app) headCommit() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = a.path.DataDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
@@ -9,9 +9,12 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/caixw/gitype/analytics"
 	"github.com/caixw/gitype/data"
+	"github.com/caixw/gitype/metrics"
 	"github.com/caixw/gitype/path"
 	"github.com/caixw/gitype/vars"
+	"github.com/issue9/logs"
 	"github.com/issue9/mux"
 )
 
@@ -21,23 +24,46 @@ type Client struct {
 	path *path.Path
 	mux  *mux.Mux
 
-	data     *data.Data
-	patterns []string // 记录所有的路由项，方便释放时删除
-	info     *info
-	updated  time.Time // 最后更新时间
-	etag     string
+	data      *data.Data
+	analytics *analytics.Analytics // 为空表示未启用访问统计
+	patterns  []string             // 记录所有的路由项，方便释放时删除
+	info      *info
+	updated   time.Time // 最后更新时间
+	etag      string
+	errors    map[int][]byte // 主题中的错误页面，以状态码为键名
 
 	postsTicker     *time.Ticker
 	postsTickerDone chan bool
 }
 
-// New 声明一个新的 Client 实例
+// New 声明一个新的 Client 实例，并注册路由。
 func New(path *path.Path, mux *mux.Mux) (*Client, error) {
+	client, err := Load(path, mux)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.Mount(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Load 加载数据并声明一个新的 Client 实例，但是不注册路由，
+// 需要调用 Mount 之后才能对外提供服务。
+//
+// 可以在加载成功之后，再替换旧的实例，加载失败时，旧的实例依然可用。
+func Load(path *path.Path, mux *mux.Mux) (*Client, error) {
 	d, err := data.Load(path)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, w := range d.Warnings() {
+		logs.Warn(w.Error())
+	}
+
 	client := &Client{
 		path:    path,
 		mux:     mux,
@@ -48,13 +74,28 @@ func New(path *path.Path, mux *mux.Mux) (*Client, error) {
 
 	client.info = client.newInfo()
 
+	if client.errors, err = loadErrorPages(path, d.Theme.ID); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Mount 注册路由并开始运行更新服务，与 Free 相对应。
+func (client *Client) Mount() error {
+	d := client.data
+
 	client.addFeed(client.data.RSS)
 	client.addFeed(client.data.Atom)
 	client.addFeed(client.data.Sitemap)
+	for _, part := range client.data.SitemapParts {
+		client.addFeed(part)
+	}
 	client.addFeed(client.data.Opensearch)
 
 	if err := client.initRoutes(); err != nil {
-		return nil, err
+		client.Free()
+		return err
 	}
 
 	// 一切数据加载都没问题之后，开始运行更新服务。
@@ -65,7 +106,21 @@ func New(path *path.Path, mux *mux.Mux) (*Client, error) {
 		client.runUpdateOutdatedServer()
 	}
 
-	return client, nil
+	return nil
+}
+
+// SetAnalytics 启用访问统计，size 为模板中热门文章的数量。
+//
+// 统计数据不随 Client 的重新加载而改变，所以由调用方创建并传入。
+func (client *Client) SetAnalytics(a *analytics.Analytics, size int) {
+	client.analytics = a
+	client.info.analytics = a
+	client.info.popularSize = size
+}
+
+// Data 返回当前的数据
+func (client *Client) Data() *data.Data {
+	return client.data
 }
 
 // Created 返回当前数据的创建时间
@@ -83,6 +138,7 @@ func (client *Client) Free() {
 	if client.postsTicker != nil {
 		client.postsTicker.Stop()
 		client.postsTickerDone <- true
+		client.postsTicker = nil
 	}
 }
 
@@ -92,10 +148,10 @@ func (client *Client) addFeed(feed *data.Feed) {
 	}
 
 	client.patterns = append(client.patterns, feed.URL)
-	client.mux.GetFunc(feed.URL, client.prepare(func(w http.ResponseWriter, r *http.Request) {
+	client.mux.GetFunc(feed.URL, metrics.Instrument(feed.URL, client.prepare(func(w http.ResponseWriter, r *http.Request) {
 		setContentType(w, feed.Type)
 		w.Write(feed.Content)
-	}))
+	})))
 }
 
 func (client *Client) runUpdateOutdatedServer() {
@@ -0,0 +1,275 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"html/template"
+	"time"
+
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/logs"
+)
+
+// 搜索关键字在 SearchAction 中的占位符
+const searchTermPlaceholder = "{search_term_string}"
+
+// 生成当前页面的 JSON-LD 结构化数据以及 Open Graph 和 Twitter Card 元数据，
+// 模板可以直接将返回值输出到 html>head 中。
+func (p *page) buildMetadata() template.HTML {
+	d := p.client.data
+	buf := new(bytes.Buffer)
+
+	for _, obj := range p.jsonLD() {
+		bs, err := json.Marshal(obj)
+		if err != nil {
+			logs.Error(err)
+			continue
+		}
+
+		buf.WriteString(`<script type="application/ld+json">`)
+		buf.Write(bs)
+		buf.WriteString("</script>\n")
+	}
+
+	for _, meta := range p.openGraph() {
+		buf.WriteString(`<meta `)
+		buf.WriteString(meta[0])
+		buf.WriteString(`="`)
+		buf.WriteString(html.EscapeString(meta[1]))
+		buf.WriteString(`" content="`)
+		buf.WriteString(html.EscapeString(meta[2]))
+		buf.WriteString("\" />\n")
+	}
+
+	// 包含文章本身，以及指向默认语言版本的 x-default
+	if p.Post != nil {
+		for _, alt := range d.PostAlternates(p.Post) {
+			writeAlternate(buf, alt[0], alt[1])
+			if alt[0] == d.Language {
+				writeAlternate(buf, "x-default", alt[1])
+			}
+		}
+	}
+
+	return template.HTML(buf.String())
+}
+
+func writeAlternate(buf *bytes.Buffer, lang, url string) {
+	buf.WriteString(`<link rel="alternate" hreflang="`)
+	buf.WriteString(html.EscapeString(lang))
+	buf.WriteString(`" href="`)
+	buf.WriteString(html.EscapeString(url))
+	buf.WriteString("\" />\n")
+}
+
+// 当前页面的 JSON-LD 对象列表
+func (p *page) jsonLD() []map[string]interface{} {
+	d := p.client.data
+	objs := make([]map[string]interface{}, 0, 2)
+
+	switch p.Type {
+	case vars.PageIndex:
+		site := map[string]interface{}{
+			"@context": "http://schema.org",
+			"@type":    "WebSite",
+			"name":     d.SiteName,
+			"url":      d.BuildURL(d.URLs.PostsURL(1)),
+		}
+		if p.Info.Opensearch != nil {
+			site["potentialAction"] = map[string]interface{}{
+				"@type":       "SearchAction",
+				"target":      d.BuildURL(d.URLs.SearchURL(searchTermPlaceholder, 0)),
+				"query-input": "required name=search_term_string",
+			}
+		}
+		objs = append(objs, site)
+	case vars.PagePost:
+		objs = append(objs, p.blogPosting())
+	}
+
+	return append(objs, p.breadcrumbList())
+}
+
+func (p *page) blogPosting() map[string]interface{} {
+	post := p.Post
+
+	obj := map[string]interface{}{
+		"@context":         "http://schema.org",
+		"@type":            "BlogPosting",
+		"headline":         post.Title,
+		"description":      post.Summary,
+		"url":              p.Canonical,
+		"mainEntityOfPage": p.Canonical,
+		"datePublished":    post.Created.Format(time.RFC3339),
+		"dateModified":     post.Modified.Format(time.RFC3339),
+		"keywords":         post.Keywords,
+		"publisher":        p.publisher(),
+	}
+
+	if post.Author != nil {
+		author := map[string]interface{}{
+			"@type": "Person",
+			"name":  post.Author.Name,
+		}
+		if len(post.Author.URL) > 0 {
+			author["url"] = post.Author.URL
+		}
+		obj["author"] = author
+	}
+
+	if image := p.image(); len(image) > 0 {
+		obj["image"] = image
+	}
+
+	if post.License != nil {
+		obj["license"] = p.absURL(post.License.URL)
+	}
+
+	return obj
+}
+
+func (p *page) publisher() map[string]interface{} {
+	d := p.client.data
+
+	obj := map[string]interface{}{
+		"@type": "Organization",
+		"name":  d.SiteName,
+	}
+
+	if d.Icon != nil {
+		obj["logo"] = map[string]interface{}{
+			"@type": "ImageObject",
+			"url":   p.absURL(d.Icon.URL),
+		}
+	}
+
+	return obj
+}
+
+// 面包屑导航，首页之后依次为：
+// 文章：首页 > 第一个标签 > 文章；
+// 其它页面：首页 > 当前页。
+func (p *page) breadcrumbList() map[string]interface{} {
+	d := p.client.data
+
+	items := make([]interface{}, 0, 3)
+	add := func(name, url string) {
+		items = append(items, map[string]interface{}{
+			"@type":    "ListItem",
+			"position": len(items) + 1,
+			"item": map[string]interface{}{
+				"@id":  url,
+				"name": name,
+			},
+		})
+	}
+
+	add(d.SiteName, d.BuildURL(d.URLs.PostsURL(1)))
+	switch p.Type {
+	case vars.PageIndex:
+	case vars.PagePost:
+		if len(p.Post.Tags) > 0 {
+			tag := p.Post.Tags[0]
+			add(tag.Title, d.BuildURL(tag.Permalink))
+		}
+		add(p.Post.Title, p.Canonical)
+	case vars.PageTag:
+		add(p.Tag.Title, p.Canonical)
+	default:
+		add(p.Title, p.Canonical)
+	}
+
+	return map[string]interface{}{
+		"@context":        "http://schema.org",
+		"@type":           "BreadcrumbList",
+		"itemListElement": items,
+	}
+}
+
+// 当前页面的 Open Graph 和 Twitter Card 元数据，
+// 每个元素依次为：属性名、属性值和 content 的值。
+func (p *page) openGraph() [][3]string {
+	d := p.client.data
+
+	title := p.Title
+	typ := "website"
+	if p.Type == vars.PagePost {
+		title = p.Post.Title
+		typ = "article"
+	}
+
+	metas := [][3]string{
+		{"property", "og:site_name", d.SiteName},
+		{"property", "og:type", typ},
+		{"property", "og:title", title},
+		{"property", "og:url", p.Canonical},
+	}
+
+	if len(p.Description) > 0 {
+		metas = append(metas, [3]string{"property", "og:description", p.Description})
+	}
+
+	image := p.image()
+	if len(image) > 0 {
+		metas = append(metas, [3]string{"property", "og:image", image})
+	}
+
+	if p.Type == vars.PagePost {
+		post := p.Post
+		metas = append(metas,
+			[3]string{"property", "article:published_time", post.Created.Format(time.RFC3339)},
+			[3]string{"property", "article:modified_time", post.Modified.Format(time.RFC3339)},
+		)
+		for _, tag := range post.Tags {
+			metas = append(metas, [3]string{"property", "article:tag", tag.Title})
+		}
+	}
+
+	// twitter card
+	card := "summary"
+	if p.Type == vars.PagePost && len(p.Post.Cover) > 0 {
+		card = "summary_large_image"
+	}
+	metas = append(metas,
+		[3]string{"name", "twitter:card", card},
+		[3]string{"name", "twitter:title", title},
+	)
+	if len(d.Twitter) > 0 {
+		metas = append(metas, [3]string{"name", "twitter:site", d.Twitter})
+	}
+	if len(p.Description) > 0 {
+		metas = append(metas, [3]string{"name", "twitter:description", p.Description})
+	}
+	if len(image) > 0 {
+		metas = append(metas, [3]string{"name", "twitter:image", image})
+	}
+
+	return metas
+}
+
+// 当前页面的代表图片，文章页为文章的封面，
+// 其它页面或是文章未指定封面的，使用网站的图标。
+func (p *page) image() string {
+	if p.Type == vars.PagePost && len(p.Post.Cover) > 0 {
+		return p.absURL(p.Post.Cover)
+	}
+
+	if icon := p.client.data.Icon; icon != nil {
+		return p.absURL(icon.URL)
+	}
+
+	return ""
+}
+
+// 将以 / 开头的站内地址转换成完整的地址
+func (p *page) absURL(url string) string {
+	if len(url) > 0 && url[0] == '/' {
+		return p.client.data.BuildURL(url)
+	}
+	return url
+}
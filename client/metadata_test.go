@@ -0,0 +1,60 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caixw/gitype/data"
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/assert"
+)
+
+func TestPage_buildMetadata(t *testing.T) {
+	a := assert.New(t)
+
+	// 首页
+	p := c.page(vars.PageIndex, nil, nil)
+	p.Title = "title"
+	p.Canonical = c.data.BuildURL("/")
+	meta := string(p.buildMetadata())
+	a.True(strings.Contains(meta, `"@type":"WebSite"`))
+	a.True(strings.Contains(meta, `"@type":"SearchAction"`)) // testdata 中配置了 opensearch
+	a.True(strings.Contains(meta, `<meta property="og:type" content="website" />`))
+
+	// 文章页
+	var post *data.Post
+	for _, item := range c.data.Posts {
+		if item.Slug == "folder/post2" {
+			post = item
+		}
+	}
+	a.NotNil(post)
+	p = c.page(vars.PagePost, nil, nil)
+	p.Post = post
+	p.Canonical = c.data.BuildURL(post.Permalink)
+	meta = string(p.buildMetadata())
+	a.True(strings.Contains(meta, `"@type":"BlogPosting"`))
+	a.True(strings.Contains(meta, `"@type":"BreadcrumbList"`))
+	a.True(strings.Contains(meta, `<meta property="og:type" content="article" />`))
+	a.True(strings.Contains(meta, `<meta property="og:image" content="https://caixw.io/posts/folder/post2/assets/image.svg" />`))
+	a.True(strings.Contains(meta, `<meta name="twitter:card" content="summary_large_image" />`))
+
+	// 有译文的文章，包含自身以及 x-default
+	post = nil
+	for _, item := range c.data.Posts {
+		if item.Slug == "post1" {
+			post = item
+		}
+	}
+	a.NotNil(post)
+	p = c.page(vars.PagePost, nil, nil)
+	p.Post = post
+	meta = string(p.buildMetadata())
+	a.True(strings.Contains(meta, `<link rel="alternate" hreflang="en" href="https://caixw.io/en/posts/post1-en.html" />`))
+	a.True(strings.Contains(meta, `<link rel="alternate" hreflang="`+c.data.Language+`" href="https://caixw.io/posts/post1.html" />`))
+	a.True(strings.Contains(meta, `<link rel="alternate" hreflang="x-default" href="https://caixw.io/posts/post1.html" />`))
+}
@@ -5,17 +5,24 @@
 package client
 
 import (
+	"html/template"
 	"io/ioutil"
 	"net/http"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/caixw/gitype/analytics"
 	"github.com/caixw/gitype/data"
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
+	"github.com/caixw/gitype/metrics"
+	"github.com/caixw/gitype/path"
 	"github.com/caixw/gitype/vars"
 	"github.com/issue9/logs"
-	"github.com/issue9/utils"
 )
 
 const contentTypeKey = "Content-Type"
@@ -51,13 +58,21 @@ type page struct {
 	Type        string       // 当前页面类型
 	Author      *data.Author // 作者
 	License     *data.Link   // 当前页的版本信息，可以为空
+	Language    string       // 当前页的语言，文章页为文章的语言，其它页面为网站的默认语言
+
+	// 当前页的 JSON-LD、Open Graph 和 Twitter Card 等元数据，
+	// 由 render() 自动生成，模板可直接输出到 html>head 中。
+	Metadata template.HTML
 
 	// 以下内容，仅在对应的页面才会有内容
-	Q        string          // 搜索关键字
-	Tag      *data.Tag       // 标签详细页面，非标签详细页，则为空
-	Posts    []*data.Post    // 文章列表，仅标签详情页和搜索页用到。
-	Post     *data.Post      // 文章详细内容，仅文章页面用到。
-	Archives []*data.Archive // 归档
+	Q        string             // 搜索关键字
+	Tag      *data.Tag          // 标签详细页面，非标签详细页，则为空
+	Posts    []*data.Post       // 文章列表，仅标签详情页、归档详细页和搜索页用到。
+	Post     *data.Post         // 文章详细内容，仅文章页面用到。
+	Related  []*data.Post       // 相关文章，仅文章页面用到。
+	Series   []*data.SeriesPart // 文章所在专题的导航信息，仅文章页面用到。
+	Archives []*data.Archive    // 归档
+	Archive  *data.Archive      // 某一时间段的归档，仅归档详细页用到。
 }
 
 // 页面的附加信息，除非重新加载数据，否则内容不会变。
@@ -69,7 +84,7 @@ type info struct {
 	Theme      *data.Theme
 
 	SiteName    string     // 网站名称
-	URL         string     // 网站地址，若是一个子目录，则需要包含该子目录
+	URL         string     // 网站地址，包含 urls.base 指定的子目录
 	Icon        *data.Icon // 网站图标
 	Language    string     // 页面语言
 	PostSize    int        // 总文章数量
@@ -80,9 +95,19 @@ type info struct {
 	Atom        *data.Link
 	Opensearch  *data.Link
 	Tags        []*data.Tag  // 标签列表
+	TagTree     []*data.Tag  // 标签树，只包含顶级标签
 	Series      []*data.Tag  // 专题列表
 	Links       []*data.Link // 友情链接
 	Menus       []*data.Link // 导航菜单
+
+	posts       []*data.Post
+	analytics   *analytics.Analytics
+	popularSize int
+
+	// 缓存的热门文章，在 analytics 的版本号变化之后才重新生成。
+	popularLocker  sync.Mutex
+	popular        []*data.Post
+	popularVersion int
 }
 
 func (client *Client) newInfo() *info {
@@ -96,7 +121,7 @@ func (client *Client) newInfo() *info {
 		Theme:      d.Theme,
 
 		SiteName:    d.SiteName,
-		URL:         d.URL,
+		URL:         d.URL + d.URLs.Base,
 		Icon:        d.Icon,
 		Language:    d.Language,
 		PostSize:    len(d.Posts),
@@ -104,9 +129,12 @@ func (client *Client) newInfo() *info {
 		Uptime:      d.Uptime,
 		LastUpdated: d.Created,
 		Tags:        d.Tags,
+		TagTree:     d.TagTree,
 		Series:      d.Series,
 		Links:       d.Links,
 		Menus:       d.Menus,
+
+		posts: d.Posts,
 	}
 
 	if d.RSS != nil {
@@ -136,6 +164,42 @@ func (client *Client) newInfo() *info {
 	return info
 }
 
+// PopularPosts 浏览量最多的文章，未启用访问统计时返回空值。
+// 模板中可以通过 {{range .Info.PopularPosts}} 的方式调用。
+func (info *info) PopularPosts() []*data.Post {
+	if info.analytics == nil {
+		return nil
+	}
+
+	info.popularLocker.Lock()
+	defer info.popularLocker.Unlock()
+
+	if v := info.analytics.Version(); v != info.popularVersion {
+		info.popular = info.popularPosts()
+		info.popularVersion = v
+	}
+	return info.popular
+}
+
+func (info *info) popularPosts() []*data.Post {
+	slugs := info.analytics.Popular(0)
+	posts := make([]*data.Post, 0, info.popularSize)
+	for _, slug := range slugs {
+		for _, post := range info.posts {
+			if post.Slug == slug {
+				posts = append(posts, post)
+				break
+			}
+		}
+
+		if len(posts) >= info.popularSize {
+			break
+		}
+	}
+
+	return posts
+}
+
 func (client *Client) page(typ string, w http.ResponseWriter, r *http.Request) *page {
 	d := client.data
 
@@ -149,9 +213,22 @@ func (client *Client) page(typ string, w http.ResponseWriter, r *http.Request) *
 		Type:     typ,
 		Author:   d.Author,
 		License:  d.License,
+		Language: d.Language,
 	}
 }
 
+// T 获取当前页面语言下 key 对应的界面文字，内容由主题的 locales 目录提供。
+// 模板中可以通过 {{.T "key"}} 的方式调用。
+func (p *page) T(key string) string {
+	return p.client.data.Message(p.Language, key)
+}
+
+// Nonce 获取当前请求的 Content-Security-Policy 中的 nonce 值，
+// 模板中内联的 script 和 style 可以通过 nonce="{{.Nonce}}" 的方式使用。
+func (p *page) Nonce() string {
+	return helper.Nonce(p.request)
+}
+
 func (p *page) nextPage(url, text string) {
 	p.NextPage = &data.Link{
 		Text: text,
@@ -171,8 +248,13 @@ func (p *page) prevPage(url, text string) {
 // 输出当前内容到指定模板
 func (p *page) render(name string) {
 	setContentType(p.response, p.client.data.Type)
+	p.response.Header().Set("Content-Language", p.Language)
 
+	p.Metadata = p.buildMetadata()
+
+	start := time.Now()
 	err := p.client.data.ExecuteTemplate(p.response, name, p)
+	metrics.RenderDuration.Since(start, name)
 	if err != nil {
 		logs.Error(err)
 		p.client.renderError(p.response, p.request, http.StatusInternalServerError)
@@ -180,28 +262,25 @@ func (p *page) render(name string) {
 	}
 }
 
+// RenderError 输出一个特定状态码下的错误页面，供程序级别的中间件使用，
+// 具体规则与 renderError 相同。
+func (client *Client) RenderError(w http.ResponseWriter, r *http.Request, code int) {
+	client.renderError(w, r, code)
+}
+
 // 输出一个特定状态码下的错误页面。
 // 若该页面模板不存在，则输出状态码对应的文本内容。
-// 只查找当前主题目录下的相关文件。
+// 只查找当前主题目录下的相关文件，内容在加载时已经缓存。
 // 只对状态码大于等于 400 的起作用。
 func (client *Client) renderError(w http.ResponseWriter, r *http.Request, code int) {
 	if code < 400 {
 		return
 	}
-	logs.Debug("输出非正常状态码：", code)
+	logs.Debug(locale.Translate("输出非正常状态码："), code)
 
 	// 根据情况输出内容，若不存在模板，则直接输出最简单的状态码对应的文本。
-	filename := strconv.Itoa(code) + vars.TemplateExtension
-	path := client.path.ThemesPath(client.data.Theme.ID, filename)
-	if !utils.FileExists(path) {
-		logs.Debugf("模板文件 %s 不存在\n", path)
-		helper.StatusError(w, code)
-		return
-	}
-
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		logs.Errorf("读取模板文件 %s 时出现以下错误: %v\n", path, err)
+	data, found := client.errors[code]
+	if !found {
 		helper.StatusError(w, code)
 		return
 	}
@@ -210,3 +289,33 @@ func (client *Client) renderError(w http.ResponseWriter, r *http.Request, code i
 	w.WriteHeader(code)
 	w.Write(data)
 }
+
+// 加载主题目录下所有以状态码命名的错误页面，比如 404.html。
+func loadErrorPages(p *path.Path, theme string) (map[int][]byte, error) {
+	dir := p.ThemesPath(theme)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[int][]byte, len(files))
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || filepath.Ext(name) != vars.TemplateExtension {
+			continue
+		}
+
+		code, err := strconv.Atoi(strings.TrimSuffix(name, vars.TemplateExtension))
+		if err != nil || code < 400 || code > 599 {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		pages[code] = data
+	}
+
+	return pages, nil
+}
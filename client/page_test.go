@@ -5,8 +5,17 @@
 package client
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/caixw/gitype/analytics"
+	"github.com/caixw/gitype/data"
+	"github.com/caixw/gitype/path"
 	"github.com/issue9/assert"
 )
 
@@ -37,3 +46,55 @@ func TestPage_prevPage(t *testing.T) {
 	a.Equal(p.PrevPage.Rel, "prev")
 	a.Equal(p.PrevPage.Text, "text")
 }
+
+func TestInfo_PopularPosts(t *testing.T) {
+	a := assert.New(t)
+
+	i := &info{
+		posts:       []*data.Post{{Slug: "p1"}, {Slug: "p2"}, {Slug: "p3"}},
+		popularSize: 2,
+	}
+	a.Nil(i.PopularPosts())
+
+	dir, err := ioutil.TempDir("", "gitype-client")
+	a.NotError(err)
+	defer os.RemoveAll(dir)
+
+	an, err := analytics.New(filepath.Join(dir, "analytics.json"))
+	a.NotError(err)
+	i.analytics = an
+	a.Empty(i.PopularPosts())
+
+	hit := func(slug string) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", "Mozilla/5.0")
+		an.Hit(r, slug)
+	}
+	hit("p3")
+	hit("p3")
+	hit("deleted") // 已经删除的文章
+	hit("deleted")
+	hit("deleted")
+	hit("p1")
+	a.Empty(i.PopularPosts()) // 保存之后才更新
+
+	a.NotError(an.Save())
+	posts := i.PopularPosts()
+	a.Equal(len(posts), 2)
+	a.Equal(posts[0].Slug, "p3")
+	a.Equal(posts[1].Slug, "p1")
+}
+
+func TestLoadErrorPages(t *testing.T) {
+	a := assert.New(t)
+
+	pages, err := loadErrorPages(path.New("../testdata"), "t1")
+	a.NotError(err).Equal(len(pages), 1)
+	a.True(strings.Contains(string(pages[http.StatusTooManyRequests]), "too many requests"))
+
+	pages, err = loadErrorPages(path.New("../testdata"), "t2")
+	a.NotError(err).Empty(pages)
+
+	pages, err = loadErrorPages(path.New("../testdata"), "not-exists")
+	a.Error(err).Nil(pages)
+}
@@ -7,8 +7,12 @@ package client
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/caixw/gitype/data"
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
+	"github.com/caixw/gitype/metrics"
 	"github.com/caixw/gitype/vars"
 	"github.com/issue9/logs"
 	"github.com/issue9/middleware/compress"
@@ -21,26 +25,36 @@ func (client *Client) initRoutes() (err error) {
 			return
 		}
 
-		client.patterns = append(client.patterns, pattern)
-		err = client.mux.HandleFunc(pattern, client.prepare(h), http.MethodGet)
+		h = metrics.Instrument(pattern, client.prepare(h))
+		if err = client.mux.HandleFunc(pattern, h, http.MethodGet); err == nil {
+			client.patterns = append(client.patterns, pattern)
+		}
 	}
 
-	handle(vars.PostURL("{slug}"), client.getPost)   // posts/2016/about.html   posts/{slug}.html
-	handle(vars.AssetURL("{path}"), client.getAsset) // posts/2016/about/abc.png  posts/{path}
-	handle(vars.IndexURL(0), client.getPosts)        // index.html
-	handle(vars.LinksURL(), client.getLinks)         // links.html
-	handle(vars.TagURL("{slug}", 1), client.getTag)  // tags/tag1.html     tags/{slug}.html
-	handle(vars.TagsURL(), client.getTags)           // tags.html
-	handle(vars.ArchivesURL(), client.getArchives)   // archives.html
-	handle(vars.SearchURL("", 1), client.getSearch)  // search.html
-	handle(vars.ThemeURL("{path}"), client.getTheme) // themes/...          themes/{path}
-	handle("/{path}", client.getRaw)                 // /...                /{path}
+	urls := client.data.URLs
+	handle(urls.PostPattern(), client.getPost) // posts/2016/about.html   posts/{slug}.html
+	for _, lang := range client.data.Languages {
+		handle(urls.Lang(lang).PostPattern(), client.getPost) // en/posts/{slug}.html
+		handle(urls.Lang(lang).IndexURL(0), client.getPosts)  // en/index.html
+	}
+	handle(urls.AssetURL("{path}"), client.getAsset) // posts/2016/about/abc.png  posts/{path}
+	handle(urls.IndexURL(0), client.getPosts)        // index.html
+	handle(urls.LinksURL(), client.getLinks)         // links.html
+	handle(urls.TagURL("{slug}", 1), client.getTag)  // tags/tag1.html     tags/{slug}.html
+	handle(urls.TagsURL(), client.getTags)           // tags.html
+	handle(urls.ArchivesURL(), client.getArchives)   // archives.html
+	if client.data.HasTemplate(vars.PageArchive) {   // archive 模板是可选的，未定义时不生成归档详细页
+		handle(urls.ArchiveURL("{date}", 1), client.getArchive) // archives/2017.html archives/{date}.html
+	}
+	handle(urls.SearchURL("", 1), client.getSearch)  // search.html
+	handle(urls.ThemeURL("{path}"), client.getTheme) // themes/...          themes/{path}
+	handle(urls.URL("/{path}"), client.getRaw)       // /...                /{path}
 
 	return err
 }
 
 // 文章详细页
-// /posts/{slug}.html
+// /posts/{slug}.html，具体格式由 urls.post 决定
 func (client *Client) getPost(w http.ResponseWriter, r *http.Request) {
 	slug, err := mux.Params(r).String("slug")
 	if err != nil {
@@ -49,46 +63,70 @@ func (client *Client) getPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	index := -1
-	for i, p := range client.data.Posts {
+	var post *data.Post
+	for _, p := range client.data.Posts {
 		if p.Slug == slug {
-			index = i
+			post = p
 			break
 		}
 	}
 
-	if index < 0 {
-		logs.Debugf("并未找到与之相对应的文章：%s", slug)
+	if post == nil {
+		logs.Debugf(locale.Translate("并未找到与之相对应的文章：%s"), slug)
 		client.getRaw(w, r) // 文章不存在，则查找 raws 目录下是否存在同名文件
 		return
 	}
 
-	post := client.data.Posts[index]
+	// 地址中的日期和语言前缀需要与文章相符，
+	// 否则同一篇文章可以通过任意日期或是语言访问到。
+	if r.URL.Path != post.Permalink {
+		logs.Debugf(locale.Translate("文章 %s 的地址与其唯一链接 %s 不符"), slug, post.Permalink)
+		client.getRaw(w, r)
+		return
+	}
+
+	if client.analytics != nil {
+		client.analytics.Hit(r, post.Slug)
+	}
+
 	p := client.page(vars.PagePost, w, r)
 
 	p.Post = post
+	p.Related = post.Related
+	p.Series = post.Series
 	p.Keywords = post.Keywords
 	p.Description = post.Summary
 	p.Title = post.HTMLTitle
 	p.Canonical = client.data.BuildURL(post.Permalink)
 	p.License = post.License // 文章可具体指定协议
 	p.Author = post.Author   // 文章可具体指定作者
+	p.Language = post.Lang
 
+	// 上一篇和下一篇只在同一语言的文章中查找
+	posts := client.data.LangPosts[post.Lang]
+	index := 0
+	for i, p := range posts {
+		if p == post {
+			index = i
+			break
+		}
+	}
 	if index > 0 {
-		prev := client.data.Posts[index-1]
+		prev := posts[index-1]
 		p.prevPage(prev.Permalink, prev.Title)
 	}
-	if index+1 < len(client.data.Posts) {
-		next := client.data.Posts[index+1]
+	if index+1 < len(posts) {
+		next := posts[index+1]
 		p.nextPage(next.Permalink, next.Title)
 	}
 
 	p.render(post.Template)
 }
 
-// 首页及文章列表页
+// 首页及文章列表页，只包含当前语言的文章
 // /
 // /index.html?page=2
+// /en/index.html?page=2
 func (client *Client) getPosts(w http.ResponseWriter, r *http.Request) {
 	page, ok := client.queryInt(w, r, vars.URLQueryPage, 1)
 	if !ok {
@@ -96,11 +134,15 @@ func (client *Client) getPosts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if page < 1 {
-		logs.Debugf("请求的页码[%d]小于1\n", page)
+		logs.Debugf(locale.Translate("请求的页码[%d]小于 1"), page)
 		client.renderError(w, r, http.StatusNotFound) // 页码为负数的表示不存在，跳转到 404 页面
 		return
 	}
 
+	lang := client.pathLang(r.URL.Path)
+	urls := client.data.LangURLs(lang)
+	posts := client.data.LangPosts[lang]
+
 	p := client.page(vars.PageIndex, w, r)
 	if page > 1 { // 非首页，标题显示页码数
 		p.Type = vars.PagePosts
@@ -109,23 +151,39 @@ func (client *Client) getPosts(w http.ResponseWriter, r *http.Request) {
 	p.Title = pp.Title
 	p.Keywords = pp.Keywords
 	p.Description = pp.Description
-	p.Canonical = client.data.BuildURL(vars.PostsURL(page))
+	p.Canonical = client.data.BuildURL(urls.PostsURL(page))
+	p.Language = lang
 
-	start, end, ok := client.getPostsRange(len(client.data.Posts), page, w, r)
+	start, end, ok := client.getPostsRange(len(posts), page, w, r)
 	if !ok {
 		return
 	}
-	p.Posts = client.data.Posts[start:end]
+	p.Posts = posts[start:end]
 	if page > 1 {
-		p.prevPage(vars.PostsURL(page-1), "")
+		p.prevPage(urls.PostsURL(page-1), "")
 	}
-	if end < len(client.data.Posts) {
-		p.nextPage(vars.PostsURL(page+1), "")
+	if end < len(posts) {
+		p.nextPage(urls.PostsURL(page+1), "")
 	}
 
 	p.render(vars.PagePosts)
 }
 
+// 根据地址中的语言前缀获取语言，没有前缀的为网站的默认语言。
+func (client *Client) pathLang(path string) string {
+	for _, lang := range client.data.Languages {
+		if strings.HasPrefix(path, client.data.URLs.URL("/"+lang+"/")) {
+			return lang
+		}
+	}
+	return client.data.Language
+}
+
+// 是否为某一语言的首页
+func (client *Client) isHome(path string) bool {
+	return path == client.data.LangURLs(client.pathLang(path)).PostsURL(1)
+}
+
 // 标签详细页
 // /tags/tag1.html?page=2
 func (client *Client) getTag(w http.ResponseWriter, r *http.Request) {
@@ -136,16 +194,18 @@ func (client *Client) getTag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var tag *data.Tag
-	for _, t := range client.data.Tags {
-		if t.Slug == slug {
-			tag = t
-			break
+	tag := client.findTag(slug)
+	if tag == nil {
+		if t := client.findTagByAlias(slug); t != nil {
+			url := client.data.URLs.TagURL(t.Slug, 1)
+			if len(r.URL.RawQuery) > 0 {
+				url += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, url, http.StatusMovedPermanently)
+			return
 		}
-	}
 
-	if tag == nil {
-		logs.Debugf("查找的标签 %s 不存在", slug)
+		logs.Debugf(locale.Translate("查找的标签 %s 不存在"), slug)
 		client.getRaw(w, r) // 标签不存在，则查找该文件是否存在于 raws 目录下。
 		return
 	}
@@ -155,7 +215,7 @@ func (client *Client) getTag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if page < 1 {
-		logs.Debugf("请求的页码[%d]小于 1", page)
+		logs.Debugf(locale.Translate("请求的页码[%d]小于 1"), page)
 		client.renderError(w, r, http.StatusNotFound) // 页码为负数的表示不存在，跳转到 404 页面
 		return
 	}
@@ -165,7 +225,7 @@ func (client *Client) getTag(w http.ResponseWriter, r *http.Request) {
 	p.Title = tag.HTMLTitle
 	p.Keywords = tag.Keywords
 	p.Description = tag.Content
-	p.Canonical = client.data.BuildURL(vars.TagURL(slug, page))
+	p.Canonical = client.data.BuildURL(client.data.URLs.TagURL(slug, page))
 
 	start, end, ok := client.getPostsRange(len(tag.Posts), page, w, r)
 	if !ok {
@@ -173,10 +233,10 @@ func (client *Client) getTag(w http.ResponseWriter, r *http.Request) {
 	}
 	p.Posts = tag.Posts[start:end]
 	if page > 1 {
-		p.prevPage(vars.TagURL(slug, page-1), "")
+		p.prevPage(client.data.URLs.TagURL(slug, page-1), "")
 	}
 	if end < len(tag.Posts) {
-		p.nextPage(vars.TagURL(slug, page+1), "")
+		p.nextPage(client.data.URLs.TagURL(slug, page+1), "")
 	}
 
 	p.render(vars.PageTag)
@@ -190,11 +250,37 @@ func (client *Client) getLinks(w http.ResponseWriter, r *http.Request) {
 	p.Title = pp.Title
 	p.Keywords = pp.Keywords
 	p.Description = pp.Description
-	p.Canonical = client.data.BuildURL(vars.LinksURL())
+	p.Canonical = client.data.BuildURL(client.data.URLs.LinksURL())
 
 	p.render(vars.PageLinks)
 }
 
+// 根据 slug 查找标签或是专题，找不到则返回 nil
+func (client *Client) findTag(slug string) *data.Tag {
+	for _, tags := range [][]*data.Tag{client.data.Tags, client.data.Series} {
+		for _, tag := range tags {
+			if tag.Slug == slug {
+				return tag
+			}
+		}
+	}
+	return nil
+}
+
+// 根据别名查找标签或是专题，找不到则返回 nil
+func (client *Client) findTagByAlias(alias string) *data.Tag {
+	for _, tags := range [][]*data.Tag{client.data.Tags, client.data.Series} {
+		for _, tag := range tags {
+			for _, a := range tag.Aliases {
+				if a == alias {
+					return tag
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // 标签列表页
 // /tags.html
 func (client *Client) getTags(w http.ResponseWriter, r *http.Request) {
@@ -203,7 +289,7 @@ func (client *Client) getTags(w http.ResponseWriter, r *http.Request) {
 	p.Title = pp.Title
 	p.Keywords = pp.Keywords
 	p.Description = pp.Description
-	p.Canonical = client.data.BuildURL(vars.TagsURL())
+	p.Canonical = client.data.BuildURL(client.data.URLs.TagsURL())
 
 	p.render(vars.PageTags)
 }
@@ -216,18 +302,80 @@ func (client *Client) getArchives(w http.ResponseWriter, r *http.Request) {
 	p.Title = pp.Title
 	p.Keywords = pp.Keywords
 	p.Description = pp.Description
-	p.Canonical = client.data.BuildURL(vars.ArchivesURL())
+	p.Canonical = client.data.BuildURL(client.data.URLs.ArchivesURL())
 	p.Archives = client.data.Archives
 
 	p.render(vars.PageArchives)
 }
 
+// 某一时间段的归档页
+// /archives/{date}.html
+func (client *Client) getArchive(w http.ResponseWriter, r *http.Request) {
+	date, err := mux.Params(r).String("date")
+	if err != nil {
+		logs.Error(err)
+		client.getRaw(w, r)
+		return
+	}
+
+	archive := client.findArchive(date)
+	if archive == nil {
+		logs.Debugf(locale.Translate("查找的归档 %s 不存在"), date)
+		client.getRaw(w, r) // 归档不存在，则查找该文件是否存在于 raws 目录下。
+		return
+	}
+
+	page, ok := client.queryInt(w, r, vars.URLQueryPage, 1)
+	if !ok {
+		return
+	}
+	if page < 1 {
+		logs.Debugf(locale.Translate("请求的页码[%d]小于 1"), page)
+		client.renderError(w, r, http.StatusNotFound) // 页码为负数的表示不存在，跳转到 404 页面
+		return
+	}
+
+	p := client.page(vars.PageArchive, w, r)
+	pp := client.data.Pages[vars.PageArchive]
+	p.Archive = archive
+	p.Title = archive.HTMLTitle
+	p.Keywords = pp.Keywords
+	p.Description = pp.Description
+	p.Canonical = client.data.BuildURL(client.data.URLs.ArchiveURL(date, page))
+
+	start, end, ok := client.getPostsRange(len(archive.Posts), page, w, r)
+	if !ok {
+		return
+	}
+	p.Posts = archive.Posts[start:end]
+	if page > 1 {
+		p.prevPage(client.data.URLs.ArchiveURL(date, page-1), "")
+	}
+	if end < len(archive.Posts) {
+		p.nextPage(client.data.URLs.ArchiveURL(date, page+1), "")
+	}
+
+	p.render(vars.PageArchive)
+}
+
+// 根据 slug 查找按年或是按月的归档，找不到则返回 nil
+func (client *Client) findArchive(slug string) *data.Archive {
+	for _, archives := range [][]*data.Archive{client.data.YearArchives, client.data.MonthArchives} {
+		for _, archive := range archives {
+			if archive.Slug == slug {
+				return archive
+			}
+		}
+	}
+	return nil
+}
+
 // 确认当前文章列表页选择范围。
 func (client *Client) getPostsRange(postsSize, page int, w http.ResponseWriter, r *http.Request) (start, end int, ok bool) {
 	size := client.data.PageSize
 	start = size * (page - 1) // 系统从零开始计数
 	if start > postsSize {
-		logs.Debugf("请求页码为[%d]，实际文章数量为[%d]\n", page, postsSize)
+		logs.Debugf(locale.Translate("请求页码为[%d]，实际文章数量为[%d]\n"), page, postsSize)
 		client.renderError(w, r, http.StatusNotFound) // 页码超出范围，不存在
 		return 0, 0, false
 	}
@@ -243,15 +391,21 @@ func (client *Client) getPostsRange(postsSize, page int, w http.ResponseWriter,
 // 每次访问前需要做的预处理工作。
 func (client *Client) prepare(f http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logs.Infof("%s: %s", r.UserAgent(), r.URL) // 输出访问日志
-
-		// 直接根据整个博客的最后更新时间来确认 etag
-		if r.Header.Get("If-None-Match") == client.etag {
-			logs.Infof("304: %s", r.URL)
-			w.WriteHeader(http.StatusNotModified)
-			return
+		// CSP 中包含 nonce 时，每次请求的页面内容都不相同，
+		// 不能使用 etag，也不能被缓存，否则缓存的页面中的 nonce 将与 CSP 报头中的不符。
+		if len(helper.Nonce(r)) > 0 {
+			w.Header().Set("Cache-Control", "no-store")
+		} else {
+			// 直接根据整个博客的最后更新时间来确认 etag
+			if r.Header.Get("If-None-Match") == client.etag {
+				metrics.Cache.Inc("etag", metrics.CacheHit)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			metrics.Cache.Inc("etag", metrics.CacheMiss)
+			w.Header().Set("Etag", client.etag)
 		}
-		w.Header().Set("Etag", client.etag)
+
 		w.Header().Set("Content-Language", client.data.Language)
 		compress.New(f, logs.ERROR()).ServeHTTP(w, r)
 	}
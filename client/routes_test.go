@@ -6,7 +6,11 @@ package client
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/issue9/assert"
 )
 
 func TestPost(t *testing.T) {
@@ -24,6 +28,22 @@ func TestPost(t *testing.T) {
 			status: http.StatusOK,
 		},
 
+		// getPost，其它语言的文章
+		{
+			path:   "/en/posts/post1-en.html",
+			status: http.StatusOK,
+		},
+
+		// getPost，语言前缀与文章的语言不符
+		{
+			path:   "/posts/post1-en.html",
+			status: http.StatusNotFound,
+		},
+		{
+			path:   "/en/posts/post1.html",
+			status: http.StatusNotFound,
+		},
+
 		// 跳转到 getRaws
 		{
 			path:    "/posts/folder/post2/raws.txt",
@@ -67,11 +87,42 @@ func TestPost(t *testing.T) {
 
 func TestRoutes(t *testing.T) {
 	testers := []*httpTester{
+		// 各语言的文章列表
+		{
+			path:   "/en/index.html",
+			status: http.StatusOK,
+		},
+		{
+			path:   "/en/",
+			status: http.StatusOK,
+		},
+		{
+			path:   "/en/index.html?page=2",
+			status: http.StatusNotFound,
+		},
+
 		// archives.html
 		{
 			path:   "/archives.html",
 			status: http.StatusOK,
 		},
+		// archives/...
+		{
+			path:   "/archives/2016.html",
+			status: http.StatusOK,
+		},
+		{
+			path:   "/archives/2016/01.html?page=1",
+			status: http.StatusOK,
+		},
+		{
+			path:   "/archives/2016.html?page=10000",
+			status: http.StatusNotFound,
+		},
+		{
+			path:   "/archives/2010.html",
+			status: http.StatusNotFound,
+		},
 		// links.html
 		{
 			path:   "/links.html",
@@ -113,6 +164,12 @@ func TestRoutes(t *testing.T) {
 			status: http.StatusOK,
 		},
 
+		// tags/... 别名，跳转到 default2
+		{
+			path:   "/tags/old-default2.html?page=1",
+			status: http.StatusOK,
+		},
+
 		// tags/...
 		{
 			path:   "/tags/not-exists.html",
@@ -129,3 +186,61 @@ func TestRoutes(t *testing.T) {
 
 	runHTTPTester(testers, t)
 }
+
+func TestRedirects(t *testing.T) {
+	testers := []*httpTester{
+		// meta/redirects.yaml 中的精确匹配
+		{
+			path:   "/old-links.html",
+			status: http.StatusOK,
+		},
+
+		// meta/redirects.yaml 中的前缀匹配，跳转到 /tags/default1.html
+		{
+			path:   "/old/default1.html",
+			status: http.StatusOK,
+		},
+
+		// 跳转之后的页面不存在
+		{
+			path:   "/old/not-exists.html",
+			status: http.StatusNotFound,
+		},
+
+		// 文章的 aliases
+		{
+			path:   "/posts/old/post1.html",
+			status: http.StatusOK,
+		},
+	}
+
+	runHTTPTester(testers, t)
+}
+
+func TestClient_prepare(t *testing.T) {
+	a := assert.New(t)
+	h := c.prepare(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	a.Equal(w.Code, http.StatusOK)
+	etag := w.Header().Get("Etag")
+	a.NotEmpty(etag)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h(w, r)
+	a.Equal(w.Code, http.StatusNotModified)
+
+	// 包含 nonce 的页面，不能返回 304，也不能被缓存
+	r = helper.WithNonce(httptest.NewRequest(http.MethodGet, "/", nil), "nonce")
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h(w, r)
+	a.Equal(w.Code, http.StatusOK)
+	a.Empty(w.Header().Get("Etag"))
+	a.Equal(w.Header().Get("Cache-Control"), "no-store")
+}
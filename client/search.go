@@ -10,6 +10,7 @@ import (
 
 	"github.com/caixw/gitype/data"
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
 	"github.com/caixw/gitype/vars"
 	"github.com/issue9/logs"
 )
@@ -20,7 +21,7 @@ func (client *Client) getSearch(w http.ResponseWriter, r *http.Request) {
 
 	q := r.FormValue(vars.URLQuerySearch)
 	if len(q) == 0 {
-		http.Redirect(w, r, vars.PostsURL(1), http.StatusPermanentRedirect)
+		http.Redirect(w, r, client.data.URLs.PostsURL(1), http.StatusPermanentRedirect)
 		return
 	}
 
@@ -29,7 +30,7 @@ func (client *Client) getSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if page < 1 {
-		logs.Debugf("参数 page: %d 小于 1", page)
+		logs.Debugf(locale.Translate("参数 page: %d 小于 1"), page)
 		client.renderError(w, r, http.StatusNotFound) // 页码为负数的表示不存在，跳转到 404 页面
 		return
 	}
@@ -39,7 +40,7 @@ func (client *Client) getSearch(w http.ResponseWriter, r *http.Request) {
 	p.Keywords = helper.ReplaceContent(pp.Keywords, q)
 	p.Description = helper.ReplaceContent(pp.Description, q)
 	p.Q = q
-	p.Canonical = client.data.BuildURL(vars.SearchURL(p.Q, page))
+	p.Canonical = client.data.BuildURL(client.data.URLs.SearchURL(p.Q, page))
 
 	posts := search(q, client.data) // 获取所有的搜索结果
 	start, end, ok := client.getPostsRange(len(posts), page, w, r)
@@ -48,10 +49,10 @@ func (client *Client) getSearch(w http.ResponseWriter, r *http.Request) {
 	}
 	p.Posts = posts[start:end]
 	if page > 1 {
-		p.prevPage(vars.SearchURL(q, page-1), "")
+		p.prevPage(client.data.URLs.SearchURL(q, page-1), "")
 	}
 	if end < len(posts) {
-		p.nextPage(vars.SearchURL(q, page+1), "")
+		p.nextPage(client.data.URLs.SearchURL(q, page+1), "")
 	}
 
 	p.render(vars.PageSearch)
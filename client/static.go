@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/caixw/gitype/vars"
 	"github.com/issue9/logs"
@@ -64,17 +65,26 @@ func (client *Client) getTheme(w http.ResponseWriter, r *http.Request) {
 
 // /...
 func (client *Client) getRaw(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path == "/" {
+	urls := client.data.URLs
+	if client.isHome(r.URL.Path) {
 		client.getPosts(w, r)
 		return
 	}
 
-	if !utils.FileExists(filepath.Join(client.path.RawsDir, r.URL.Path)) {
+	if !utils.FileExists(filepath.Join(client.path.RawsDir, strings.TrimPrefix(r.URL.Path, urls.Base))) {
+		if url, status, found := client.data.Redirect(r.URL.Path); found {
+			if len(r.URL.RawQuery) > 0 && !strings.Contains(url, "?") {
+				url += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, url, status)
+			return
+		}
+
 		client.renderError(w, r, http.StatusNotFound)
 		return
 	}
 
-	prefix := "/"
+	prefix := urls.URL("/")
 	root := http.Dir(client.path.RawsDir)
 	http.StripPrefix(prefix, http.FileServer(root)).ServeHTTP(w, r)
 }
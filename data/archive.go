@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/vars"
 )
 
 // 归档的类型
@@ -23,11 +24,23 @@ const (
 	archiveOrderAsc  = "asc"
 )
 
+// 各归档类型默认的标题格式
+const (
+	archiveYearFormat  = "2006"
+	archiveMonthFormat = "2006-01"
+)
+
 // Archive 表示某一时间段的存档信息
 type Archive struct {
-	date  time.Time // 当前存档的一个日期值，可用于生成 Title 和排序用，具体取值方式，可自定义
-	Title string    // 当前存档的标题
-	Posts []*Post   // 当前存档的文章列表
+	date      time.Time // 当前存档的一个日期值，可用于生成 Title 和排序用，具体取值方式，可自定义
+	Title     string    // 当前存档的标题
+	Posts     []*Post   // 当前存档的文章列表
+	Slug      string    // 唯一名称，按年为 2017，按月为 2017/05
+	Permalink string    // 归档详细页的地址，指向第一页
+	HTMLTitle string    // 用于网页的标题
+	Modified  time.Time // 所有文章中最迟修改的
+	Prev      *Archive  // 上一个时间段，即更早的归档，不存在则为空
+	Next      *Archive  // 下一个时间段，即更晚的归档，不存在则为空
 }
 
 // 存档页的配置内容
@@ -37,49 +50,105 @@ type archiveConfig struct {
 	Format string `yaml:"format,omitempty"` // 标题的格式化字符串
 }
 
+// 生成按年和按月的归档信息。
+//
+// Data.Archives 为 archive.type 指定的归档方式，用于 /archives.html；
+// 同时按年和按月的归档都有其详细页，分别保存在 YearArchives 和 MonthArchives 中。
 func (d *Data) buildArchives(conf *config) error {
+	yearFormat, monthFormat := archiveYearFormat, archiveMonthFormat
+
+	switch conf.Archive.Type {
+	case archiveTypeMonth:
+		monthFormat = conf.Archive.Format
+	case archiveTypeYear:
+		yearFormat = conf.Archive.Format
+	default:
+		return &helper.FieldError{File: d.path.MetaConfigFile, Field: "archive.type", Message: "无效的取值"}
+	}
+
+	title := conf.Pages[vars.PageArchive].Title
+	d.YearArchives = buildArchiveList(d.Posts, d.URLs, archiveTypeYear, yearFormat, title, conf.Archive.Order)
+	d.MonthArchives = buildArchiveList(d.Posts, d.URLs, archiveTypeMonth, monthFormat, title, conf.Archive.Order)
+
+	if conf.Archive.Type == archiveTypeMonth {
+		d.Archives = d.MonthArchives
+	} else {
+		d.Archives = d.YearArchives
+	}
+
+	return nil
+}
+
+// 拥有详细页的归档列表。主题未定义 archive 模板时，不存在归档详细页。
+func (d *Data) detailArchives() [][]*Archive {
+	if !d.HasTemplate(vars.PageArchive) {
+		return nil
+	}
+	return [][]*Archive{d.YearArchives, d.MonthArchives}
+}
+
+// 将文章按 typ 指定的类型进行归档，并按 order 进行排序。
+func buildArchiveList(posts []*Post, urls *vars.URLs, typ, format, title, order string) []*Archive {
 	archives := make([]*Archive, 0, 10)
 
-	for _, post := range d.Posts {
+	for _, post := range posts {
 		t := post.Created
 		var date time.Time
+		var slug string
 
-		switch conf.Archive.Type {
-		case archiveTypeMonth:
+		if typ == archiveTypeMonth {
 			date = time.Date(t.Year(), t.Month(), 2, 0, 0, 0, 0, t.Location())
-		case archiveTypeYear:
+			slug = date.Format("2006/01")
+		} else {
 			date = time.Date(t.Year(), 2, 0, 0, 0, 0, 0, t.Location())
-		default:
-			return &helper.FieldError{File: d.path.MetaConfigFile, Field: "archive.type", Message: "无效的取值"}
+			slug = date.Format("2006")
 		}
 
-		found := false
-		for _, archive := range archives {
-			if archive.date.Equal(date) {
-				archive.Posts = append(archive.Posts, post)
-				found = true
+		var archive *Archive
+		for _, a := range archives {
+			if a.date.Equal(date) {
+				archive = a
 				break
 			}
 		}
-		if !found {
-			archives = append(archives, &Archive{
-				date:  date,
-				Title: date.Format(conf.Archive.Format),
-				Posts: []*Post{post},
-			})
+		if archive == nil {
+			archive = &Archive{
+				date:      date,
+				Title:     date.Format(format),
+				Slug:      slug,
+				Permalink: urls.ArchiveURL(slug, 1),
+				Posts:     make([]*Post, 0, 10),
+			}
+			archive.HTMLTitle = helper.ReplaceContent(title, archive.Title)
+			archives = append(archives, archive)
+		}
+
+		archive.Posts = append(archive.Posts, post)
+		if archive.Modified.Before(post.Modified) {
+			archive.Modified = post.Modified
 		}
 	} // end for
 
+	// 按时间先后关联前后的归档
 	sort.SliceStable(archives, func(i, j int) bool {
-		if conf.Archive.Order == archiveOrderDesc {
-			return archives[i].date.After(archives[j].date)
-		}
 		return archives[i].date.Before(archives[j].date)
 	})
+	for i, archive := range archives {
+		if i > 0 {
+			archive.Prev = archives[i-1]
+		}
+		if i+1 < len(archives) {
+			archive.Next = archives[i+1]
+		}
+	}
 
-	d.Archives = archives
+	if order == archiveOrderDesc {
+		sort.SliceStable(archives, func(i, j int) bool {
+			return archives[i].date.After(archives[j].date)
+		})
+	}
 
-	return nil
+	return archives
 }
 
 func (a *archiveConfig) sanitize() *helper.FieldError {
@@ -91,6 +160,14 @@ func (a *archiveConfig) sanitize() *helper.FieldError {
 		}
 	}
 
+	if len(a.Format) == 0 {
+		if a.Type == archiveTypeMonth {
+			a.Format = archiveMonthFormat
+		} else {
+			a.Format = archiveYearFormat
+		}
+	}
+
 	if len(a.Order) == 0 {
 		a.Order = archiveOrderDesc
 	} else {
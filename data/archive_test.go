@@ -0,0 +1,59 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/assert"
+)
+
+func TestBuildArchiveList(t *testing.T) {
+	a := assert.New(t)
+
+	newPost := func(year int, month time.Month) *Post {
+		return &Post{Created: time.Date(year, month, 10, 0, 0, 0, 0, time.UTC)}
+	}
+	posts := []*Post{
+		newPost(2017, 5),
+		newPost(2017, 5),
+		newPost(2017, 3),
+		newPost(2016, 12),
+	}
+
+	// 按年，倒序
+	archives := buildArchiveList(posts, vars.NewURLs(), archiveTypeYear, "2006 年", "归档："+vars.ContentPlaceholder, archiveOrderDesc)
+	a.Equal(len(archives), 2)
+	a.Equal(archives[0].Slug, "2017").Equal(len(archives[0].Posts), 3)
+	a.Equal(archives[0].Title, "2017 年").Equal(archives[0].HTMLTitle, "归档：2017 年")
+	a.Equal(archives[0].Permalink, vars.NewURLs().ArchiveURL("2017", 1))
+	a.Equal(archives[0].Prev, archives[1]).Nil(archives[0].Next)
+	a.Equal(archives[1].Slug, "2016").Equal(archives[1].Next, archives[0]).Nil(archives[1].Prev)
+
+	// 按月，正序
+	archives = buildArchiveList(posts, vars.NewURLs(), archiveTypeMonth, "2006-01", "", archiveOrderAsc)
+	a.Equal(len(archives), 3)
+	a.Equal(archives[0].Slug, "2016/12")
+	a.Equal(archives[1].Slug, "2017/03")
+	a.Equal(archives[2].Slug, "2017/05").Equal(len(archives[2].Posts), 2)
+	a.Equal(archives[1].Prev, archives[0]).Equal(archives[1].Next, archives[2])
+}
+
+func TestData_detailArchives(t *testing.T) {
+	a := assert.New(t)
+
+	d, err := Load(testdataPath)
+	a.NotError(err).NotNil(d)
+	a.True(d.HasTemplate(vars.PageArchive))
+	a.Equal(len(d.detailArchives()), 2)
+
+	// 未定义 archive 模板的主题
+	d.Theme.template = template.Must(template.New(vars.PageArchives).Parse("archives"))
+	a.False(d.HasTemplate(vars.PageArchive))
+	a.Nil(d.detailArchives())
+}
@@ -5,6 +5,7 @@
 package data
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/caixw/gitype/helper"
@@ -22,9 +23,9 @@ func (d *Data) buildAtom(conf *config) error {
 		"xmlns":            "http://www.w3.org/2005/Atom",
 		"xmlns:opensearch": "http://a9.com/-/spec/opensearch/1.1/",
 	})
-	w.WriteElement("id", conf.URL, nil)
+	w.WriteElement("id", d.URL+d.URLs.Base, nil)
 	w.WriteCloseElement("link", map[string]string{
-		"href": conf.URL,
+		"href": d.URL + d.URLs.Base,
 	})
 
 	if conf.Opensearch != nil {
@@ -32,7 +33,7 @@ func (d *Data) buildAtom(conf *config) error {
 		w.WriteCloseElement("link", map[string]string{
 			"rel":   "search",
 			"type":  o.Type,
-			"href":  d.BuildURL(o.URL),
+			"href":  d.BuildURL(d.URLs.URL(o.URL)),
 			"title": o.Title,
 		})
 	}
@@ -41,7 +42,7 @@ func (d *Data) buildAtom(conf *config) error {
 	w.WriteElement("subtitle", conf.Subtitle, nil)
 	w.WriteElement("update", d.Created.Format(time.RFC3339), nil)
 
-	addPostsToAtom(w, d)
+	addPostsToAtom(w, d, conf.Atom)
 
 	w.WriteEndElement("feed")
 
@@ -51,7 +52,7 @@ func (d *Data) buildAtom(conf *config) error {
 	}
 	d.Atom = &Feed{
 		Title:   conf.Atom.Title,
-		URL:     conf.Atom.URL,
+		URL:     d.URLs.URL(conf.Atom.URL),
 		Type:    conf.Atom.Type,
 		Content: bs,
 	}
@@ -59,7 +60,7 @@ func (d *Data) buildAtom(conf *config) error {
 	return nil
 }
 
-func addPostsToAtom(w *helper.XMLWriter, d *Data) {
+func addPostsToAtom(w *helper.XMLWriter, d *Data, atom *rssConfig) {
 	for _, p := range d.Posts {
 		w.WriteStartElement("entry", nil)
 
@@ -69,6 +70,14 @@ func addPostsToAtom(w *helper.XMLWriter, d *Data) {
 			"href": d.BuildURL(p.Permalink),
 		})
 
+		for _, t := range p.Translations {
+			w.WriteCloseElement("link", map[string]string{
+				"rel":      "alternate",
+				"hreflang": t.Lang,
+				"href":     d.BuildURL(t.Permalink),
+			})
+		}
+
 		w.WriteElement("title", p.Title, nil)
 
 		w.WriteElement("update", p.Modified.Format(time.RFC3339), nil)
@@ -77,6 +86,21 @@ func addPostsToAtom(w *helper.XMLWriter, d *Data) {
 			"type": "html",
 		})
 
+		if atom.Content == rssContentFull {
+			w.WriteCDATAElement("content", d.feedContent(p), map[string]string{
+				"type": "html",
+			})
+		}
+
+		for _, e := range p.Enclosures {
+			w.WriteCloseElement("link", map[string]string{
+				"rel":    "enclosure",
+				"href":   d.enclosureURL(e),
+				"length": strconv.FormatInt(e.Length, 10),
+				"type":   e.Type,
+			})
+		}
+
 		w.WriteEndElement("entry")
 	}
 }
@@ -0,0 +1,236 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/path"
+	"github.com/issue9/utils"
+)
+
+// 匹配 yaml 错误信息中的行号，比如 yaml: line 5: ...
+var yamlLineExpr = regexp.MustCompile(`\bline (\d+)\b`)
+
+type checker struct {
+	path *path.Path
+	errs helper.FieldErrors
+}
+
+// Check 检测 path 下的所有数据。
+//
+// 与 Load 不同，Check 不会在第一个错误处返回，而是尽可能多地找出所有的错误，
+// 包括各个文件中的格式错误、不存在的标签、重名、未定义的模板、
+// 无效的站内链接以及不存在的资源文件等。
+// 返回的错误按文件和行号排序，没有错误时返回空值。
+func Check(path *path.Path) helper.FieldErrors {
+	c := &checker{path: path}
+
+	conf, err := loadConfig(path)
+	if err != nil { // 配置文件是其它数据的基础，出错则无法继续
+		c.add(path.MetaConfigFile, err)
+		return c.result()
+	}
+
+	tags, err := loadTags(path, conf.URLs)
+	c.add(path.MetaTagsFile, err)
+
+	links, err := loadLinks(path)
+	c.add(path.MetaLinksFile, err)
+
+	redirects, err := loadRedirects(path)
+	c.add(path.MetaRedirectsFile, err)
+
+	posts := c.loadPosts(conf)
+
+	theme, err := findTheme(path, conf)
+	c.add(path.ThemesPath(conf.Theme), err)
+
+	// 缺少关联数据，无法进行后续的检测
+	if tags == nil || theme == nil {
+		return c.result()
+	}
+	conf.initPages(theme)
+
+	d := newData(path, conf)
+	d.Tags = tags
+	d.Links = links
+	d.Posts = posts
+	d.Theme = theme
+	d.Redirects = redirects
+
+	// 模板错误并不影响其它数据的检测
+	c.add(path.ThemesPath(conf.Theme), d.compileTemplate())
+
+	// 出错的部分会被忽略，但不影响之后的检测
+	c.add("", d.sanitize(conf))
+	c.add("", d.warnings)
+	c.add("", d.buildData(conf))
+
+	c.checkPostLinks(d)
+
+	return c.result()
+}
+
+// 逐篇加载文章，出错的文章会被忽略，但不影响其它文章的加载。
+func (c *checker) loadPosts(conf *config) []*Post {
+	slugs, err := findPostSlugs(c.path)
+	if err != nil {
+		c.add(c.path.PostsDir, err)
+		return nil
+	}
+
+	posts := make([]*Post, 0, len(slugs))
+	for _, slug := range slugs {
+		post, err := loadPost(c.path, conf.URLs, slug)
+		if err != nil {
+			c.add(c.path.PostMetaPath(slug), err)
+			continue
+		}
+
+		if !post.Draft {
+			posts = append(posts, post)
+		}
+	}
+
+	c.add(c.path.PostsDir, checkPostsDup(posts))
+
+	sortPosts(posts)
+
+	return posts
+}
+
+// 检测文章中的站内链接、封面和媒体文件是否真实存在。
+func (c *checker) checkPostLinks(d *Data) {
+	routes := d.routes()
+
+	for _, post := range d.Posts {
+		meta := c.path.PostMetaPath(post.Slug)
+
+		if isLocalURL(post.Cover) && !d.linkExists(routes, post.Cover) {
+			c.addField(meta, "cover", "文件不存在："+post.Cover)
+		}
+
+		for index, e := range post.Enclosures {
+			if isLocalURL(e.URL) && !d.linkExists(routes, e.URL) {
+				c.addField(meta, "enclosures["+strconv.Itoa(index)+"].url", "文件不存在："+e.URL)
+			}
+		}
+
+		base, err := url.Parse(post.Permalink)
+		if err != nil {
+			continue
+		}
+
+		links := appendHTMLLinks(nil, post.Content, base, c.path.PostContentPath(post.Slug))
+		for _, l := range links {
+			if b, _ := d.checkInternalLink(routes, l); b != nil {
+				c.errs = append(c.errs, &helper.FieldError{
+					File:    b.File,
+					Message: b.Message + "：" + b.URL,
+					Line:    b.Line,
+				})
+			}
+		}
+	}
+}
+
+// 站内地址 p 是否存在，routes 为 Data.routes() 的返回值。
+//
+// 除了各类页面之外，还包括文章目录、主题目录和 raws 目录下的文件，
+// 以及 meta/redirects.yaml 中的跳转规则。
+func (d *Data) linkExists(routes map[string]bool, p string) bool {
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+
+	if routes[p] {
+		return true
+	}
+
+	if _, _, found := d.Redirect(p); found {
+		return true
+	}
+
+	dirs := map[string]string{
+		d.URLs.AssetURL(""): d.path.PostsDir,
+		d.URLs.ThemeURL(""): d.path.ThemesDir,
+		d.URLs.URL("/"):     d.path.RawsDir,
+	}
+	for prefix, dir := range dirs {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+
+		file := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(p, prefix)))
+		if utils.FileExists(file) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 是否为以 / 开头的站内地址
+func isLocalURL(addr string) bool {
+	return len(addr) > 0 && addr[0] == '/' && !strings.HasPrefix(addr, "//")
+}
+
+// 添加一条错误信息，file 为错误所在的文件，
+// 若 err 本身已经包含了文件信息，则以 err 中的为准。
+func (c *checker) add(file string, err error) {
+	switch e := err.(type) {
+	case nil:
+		return
+	case helper.FieldErrors:
+		c.errs = append(c.errs, e...)
+	case *helper.FieldError:
+		if e == nil {
+			return
+		}
+		if len(e.File) == 0 {
+			e.File = file
+		}
+		c.errs = append(c.errs, e)
+	default:
+		fe := &helper.FieldError{File: file, Message: err.Error()}
+		if matches := yamlLineExpr.FindStringSubmatch(fe.Message); len(matches) > 1 {
+			fe.Line, _ = strconv.Atoi(matches[1])
+		}
+		c.errs = append(c.errs, fe)
+	}
+}
+
+func (c *checker) addField(file, field, message string) {
+	c.errs = append(c.errs, &helper.FieldError{File: file, Field: field, Message: message})
+}
+
+// 补全行号并按文件和行号排序
+func (c *checker) result() helper.FieldErrors {
+	if len(c.errs) == 0 {
+		return nil
+	}
+
+	for _, err := range c.errs {
+		if err.Line == 0 && len(err.Field) > 0 && filepath.Ext(err.File) == ".yaml" {
+			err.Line = helper.FieldLine(err.File, err.Field)
+		}
+	}
+
+	sort.SliceStable(c.errs, func(i, j int) bool {
+		if c.errs[i].File != c.errs[j].File {
+			return c.errs[i].File < c.errs[j].File
+		}
+		return c.errs[i].Line < c.errs[j].Line
+	})
+
+	return c.errs
+}
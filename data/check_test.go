@@ -0,0 +1,71 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/path"
+	"github.com/issue9/assert"
+)
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+
+	errs := Check(testdataPath)
+	a.Empty(errs, errs.Error())
+
+	// 每个文件中的多个错误都能被找出
+	p := path.New("../testdata/check")
+	errs = Check(p)
+	count := map[string]int{}
+	for _, err := range errs {
+		count[err.File]++
+	}
+	a.Equal(count[p.MetaTagsFile], 2, errs.Error())
+	a.Equal(count[p.MetaRedirectsFile], 2, errs.Error())
+	a.Equal(count[p.PostMetaPath("post1")], 2, errs.Error())
+	a.Equal(len(errs), 6, errs.Error())
+}
+
+func TestData_linkExists(t *testing.T) {
+	a := assert.New(t)
+	d, err := Load(testdataPath)
+	a.NotError(err).NotNil(d)
+	routes := d.routes()
+
+	a.True(d.linkExists(routes, "/posts/post1.html"))
+	a.True(d.linkExists(routes, "/posts/post1.html#anchor"))
+	a.True(d.linkExists(routes, "/tags/default1.html?page=2"))
+	a.True(d.linkExists(routes, "/posts/folder/post2/assets/assets.txt")) // 文章资源
+	a.True(d.linkExists(routes, "/themes/t1/style.css"))                  // 主题文件
+	a.True(d.linkExists(routes, "/old-links.html"))                       // 跳转规则
+	a.False(d.linkExists(routes, "/posts/not-exists.html"))
+	a.False(d.linkExists(routes, "/themes/t1/not-exists.css"))
+}
+
+func TestChecker_add(t *testing.T) {
+	a := assert.New(t)
+	c := &checker{path: testdataPath}
+
+	c.add("f1", nil)
+	var fe *helper.FieldError
+	c.add("f1", fe)
+	a.Empty(c.errs)
+
+	c.add("f1", errors.New("yaml: line 5: mapping values are not allowed"))
+	c.add("f1", &helper.FieldError{Message: "m1", Line: 2})
+	c.add("f1", &helper.FieldError{File: "f0", Message: "m2"})
+	c.add("f2", helper.FieldErrors{{File: "f2", Message: "m3"}, {File: "f2", Message: "m4"}})
+	a.Equal(len(c.errs), 5)
+
+	errs := c.result()
+	a.Equal(errs[0].File, "f0")
+	a.Equal(errs[1].Line, 2).Equal(errs[1].Message, "m1")
+	a.Equal(errs[2].Line, 5)
+	a.Equal(errs[3].File, "f2")
+}
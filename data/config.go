@@ -29,6 +29,7 @@ type config struct {
 	Title           string        `yaml:"title"`
 	TitleSeparator  string        `yaml:"titleSeparator"`
 	Language        string        `yaml:"language"`
+	Languages       []string      `yaml:"languages,omitempty"` // 除 language 之外，文章可使用的其它语言
 	Subtitle        string        `yaml:"subtitle,omitempty"`
 	URL             string        `yaml:"url"` // 网站的域名，非默认端口也得包含，不包含最后的斜杠，仅在生成地址时使用
 	Beian           string        `yaml:"beian,omitempty"`
@@ -42,6 +43,15 @@ type config struct {
 	LongDateFormat  string        `yaml:"longDateFormat"`
 	ShortDateFormat string        `yaml:"shortDateFormat"`
 	Outdated        time.Duration `yaml:"outdated"`
+	Twitter         string        `yaml:"twitter,omitempty"`     // 网站的 Twitter 账号，用于 twitter:site
+	RelatedSize     int           `yaml:"relatedSize,omitempty"` // 每篇文章的相关文章数量，默认为 5
+
+	// 文章引用了不存在的标签时，是否自动创建该标签。
+	// 为 false 时，忽略该标签，仅作为警告输出到日志，Check 时则作为错误报告。
+	AutoTags bool `yaml:"autoTags,omitempty"`
+
+	// 各类页面地址的构成方式，未指定的字段使用默认值。
+	URLs *vars.URLs `yaml:"urls,omitempty"`
 
 	// 各个页面的一些自定义项，目前支持以下几个元素的修改：
 	// 1) html>head>title
@@ -60,73 +70,107 @@ type config struct {
 }
 
 func loadConfig(path *path.Path) (*config, error) {
-	conf := &config{}
+	conf := &config{URLs: vars.NewURLs()} // 预先填充默认值，配置文件中只需要指定有变化的字段
 	if err := helper.LoadYAMLFile(path.MetaConfigFile, conf); err != nil {
 		return nil, err
 	}
 
-	if err := conf.sanitize(); err != nil {
-		err.File = path.MetaConfigFile
-		return nil, err
+	if errs := conf.sanitize(); len(errs) > 0 {
+		for _, err := range errs {
+			err.File = path.MetaConfigFile
+		}
+		return nil, errs
 	}
 
 	return conf, nil
 }
 
-func (conf *config) sanitize() *helper.FieldError {
+// 检测并修正配置项，返回所有的错误信息，而不是在第一个错误处返回。
+func (conf *config) sanitize() (errs helper.FieldErrors) {
+	add := func(err *helper.FieldError) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	if len(conf.Language) == 0 {
 		conf.Language = language
 	}
 
+	for index, lang := range conf.Languages {
+		field := "languages[" + strconv.Itoa(index) + "]"
+		switch {
+		case len(lang) == 0:
+			add(&helper.FieldError{Message: "不能为空", Field: field})
+		case strings.ContainsAny(lang, "/?#"):
+			add(&helper.FieldError{Message: "包含非法字符", Field: field})
+		case lang == conf.Language:
+			add(&helper.FieldError{Message: "不能与 language 相同", Field: field})
+		case inStrings(conf.Languages[:index], lang):
+			add(&helper.FieldError{Message: "重复的值", Field: field})
+		}
+	}
+
 	if conf.PageSize <= 0 {
-		return &helper.FieldError{Message: "必须为大于零的整数", Field: "pageSize"}
+		add(&helper.FieldError{Message: "必须为大于零的整数", Field: "pageSize"})
 	}
 
 	if len(conf.LongDateFormat) == 0 {
-		return &helper.FieldError{Message: "不能为空", Field: "longDateFormat"}
+		add(&helper.FieldError{Message: "不能为空", Field: "longDateFormat"})
 	}
 
 	if len(conf.ShortDateFormat) == 0 {
-		return &helper.FieldError{Message: "不能为空", Field: "shortDateFormat"}
+		add(&helper.FieldError{Message: "不能为空", Field: "shortDateFormat"})
 	}
 
 	t, err := time.Parse(vars.DateFormat, conf.UptimeFormat)
 	if err != nil {
-		return &helper.FieldError{Message: err.Error(), Field: "uptimeFormat"}
+		add(&helper.FieldError{Message: err.Error(), Field: "uptimeFormat"})
 	}
 	conf.Uptime = t
 
 	if conf.Outdated < 0 {
-		return &helper.FieldError{Message: "必须大于 0", Field: "outdated"}
+		add(&helper.FieldError{Message: "必须大于 0", Field: "outdated"})
+	}
+
+	if conf.RelatedSize < 0 {
+		add(&helper.FieldError{Message: "不能小于 0", Field: "relatedSize"})
+	} else if conf.RelatedSize == 0 {
+		conf.RelatedSize = relatedSize
 	}
 
 	if len(conf.Type) == 0 {
 		conf.Type = contentTypeHTML
 	}
 
+	if conf.URLs == nil {
+		conf.URLs = vars.NewURLs()
+	} else {
+		add(sanitizeURLs(conf.URLs))
+	}
+
 	// icon
 	if conf.Icon != nil {
 		if err := conf.Icon.sanitize(); err != nil {
 			err.Field = "icon." + err.Field
-			return err
+			add(err)
 		}
 	}
 
 	// Author
 	if conf.Author == nil {
-		return &helper.FieldError{Message: "必须指定作者", Field: "author"}
-	}
-	if err := conf.Author.sanitize(); err != nil {
+		add(&helper.FieldError{Message: "必须指定作者", Field: "author"})
+	} else if err := conf.Author.sanitize(); err != nil {
 		err.Field = "author." + err.Field
-		return err
+		add(err)
 	}
 
 	if len(conf.Title) == 0 {
-		return &helper.FieldError{Message: "不能为空", Field: "title"}
+		add(&helper.FieldError{Message: "不能为空", Field: "title"})
 	}
 
 	if !is.URL(conf.URL) {
-		return &helper.FieldError{Message: "不是一个合法的域名或 IP", Field: "url"}
+		add(&helper.FieldError{Message: "不是一个合法的域名或 IP", Field: "url"})
 	}
 	if strings.HasSuffix(conf.URL, "/") {
 		conf.URL = conf.URL[:len(conf.URL)-1]
@@ -134,63 +178,92 @@ func (conf *config) sanitize() *helper.FieldError {
 
 	// theme
 	if len(conf.Theme) == 0 {
-		return &helper.FieldError{Message: "不能为空", Field: "theme"}
+		add(&helper.FieldError{Message: "不能为空", Field: "theme"})
 	}
 
 	// archive
 	if conf.Archive == nil {
-		return &helper.FieldError{Message: "不能为空", Field: "archive"}
-	}
-	if err := conf.Archive.sanitize(); err != nil {
-		return err
+		add(&helper.FieldError{Message: "不能为空", Field: "archive"})
+	} else {
+		add(conf.Archive.sanitize())
 	}
 
 	// license
 	if conf.License == nil {
-		return &helper.FieldError{Message: "不能为空", Field: "license"}
-	}
-	if err := conf.License.sanitize(); err != nil {
+		add(&helper.FieldError{Message: "不能为空", Field: "license"})
+	} else if err := conf.License.sanitize(); err != nil {
 		err.Field = "license." + err.Field
-		return err
+		add(err)
 	}
 
 	// rss
 	if conf.RSS != nil {
-		if err := conf.RSS.sanitize(conf, "rss"); err != nil {
-			return err
-		}
+		add(conf.RSS.sanitize(conf, "rss"))
 	}
 
 	// atom
 	if conf.Atom != nil {
-		if err := conf.Atom.sanitize(conf, "atom"); err != nil {
-			return err
-		}
+		add(conf.Atom.sanitize(conf, "atom"))
 	}
 
 	// sitemap
 	if conf.Sitemap != nil {
-		if err := conf.Sitemap.sanitize(); err != nil {
-			return err
-		}
+		add(conf.Sitemap.sanitize())
 	}
 
 	// opensearch，需要用到 config.Icon 变量
 	if conf.Opensearch != nil {
-		if err := conf.Opensearch.sanitize(conf); err != nil {
-			return err
-		}
+		add(conf.Opensearch.sanitize(conf))
 	}
 
 	// menus
 	for index, link := range conf.Menus {
 		if err := link.sanitize(); err != nil {
 			err.Field = "Menus[" + strconv.Itoa(index) + "]." + err.Field
-			return err
+			add(err)
 		}
 	}
 
-	conf.initPages()
+	return errs
+}
+
+// 检测地址配置是否正确
+func sanitizeURLs(u *vars.URLs) *helper.FieldError {
+	if len(u.Base) > 0 && (u.Base[0] != '/' || u.Base[len(u.Base)-1] == '/') {
+		return &helper.FieldError{Message: "必须以 / 开头，且不能以 / 结尾", Field: "urls.base"}
+	}
+
+	if len(u.Post) == 0 || u.Post[0] != '/' {
+		return &helper.FieldError{Message: "必须以 / 开头", Field: "urls.post"}
+	}
+	if !strings.Contains(u.Post, vars.URLPlaceholderSlug) {
+		return &helper.FieldError{Message: "必须包含占位符：" + vars.URLPlaceholderSlug, Field: "urls.post"}
+	}
+
+	prefixes := []struct{ field, val string }{
+		{"urls.index", u.Index},
+		{"urls.tags", u.Tags},
+		{"urls.links", u.Links},
+		{"urls.archives", u.Archives},
+		{"urls.search", u.Search},
+		{"urls.themes", u.Themes},
+		{"urls.assets", u.Assets},
+	}
+	for _, p := range prefixes {
+		if len(p.val) < 2 || p.val[0] != '/' || p.val[len(p.val)-1] == '/' {
+			return &helper.FieldError{Message: "必须以 / 开头，且不能以 / 结尾", Field: p.field}
+		}
+	}
+
+	if strings.ContainsAny(u.Suffix, "/?#") {
+		return &helper.FieldError{Message: "不能包含 /、? 和 #", Field: "urls.suffix"}
+	}
+
+	// 没有后缀时，文章地址与资源文件地址无法区分，比如 /posts/{slug} 和 /posts/{path}
+	prefix := u.Post[:strings.Index(u.Post, "{")]
+	if len(u.Suffix) == 0 && strings.HasPrefix(prefix, u.Assets+"/") {
+		return &helper.FieldError{Message: "后缀为空时，不能与 urls.assets 有相同的前缀", Field: "urls.post"}
+	}
 
 	return nil
 }
@@ -0,0 +1,70 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/assert"
+)
+
+func TestConfig_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	// 返回所有的错误，而不是第一个
+	conf := &config{PageSize: 20, UptimeFormat: "2016-01-02T12:11:01+08:00"}
+	errs := conf.sanitize()
+	fields := make([]string, 0, len(errs))
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	a.Equal(fields, []string{
+		"longDateFormat", "shortDateFormat", "author", "title", "url", "theme", "archive", "license",
+	})
+}
+
+func TestSanitizeURLs(t *testing.T) {
+	a := assert.New(t)
+
+	u := vars.NewURLs()
+	a.NotError(sanitizeURLs(u))
+
+	// 无后缀，且文章地址包含日期
+	u.Suffix = ""
+	u.Post = "/{year}/{month}/{slug}/"
+	a.NotError(sanitizeURLs(u))
+
+	u.Post = "/{year}/{month}/"
+	a.Equal(sanitizeURLs(u).Field, "urls.post")
+
+	u.Post = "posts/{slug}"
+	a.Equal(sanitizeURLs(u).Field, "urls.post")
+
+	u = vars.NewURLs()
+	u.Tags = "/tags/"
+	a.Equal(sanitizeURLs(u).Field, "urls.tags")
+
+	u = vars.NewURLs()
+	u.Assets = ""
+	a.Equal(sanitizeURLs(u).Field, "urls.assets")
+
+	u = vars.NewURLs()
+	u.Base = "/blog"
+	a.NotError(sanitizeURLs(u))
+	u.Base = "/blog/"
+	a.Equal(sanitizeURLs(u).Field, "urls.base")
+
+	u = vars.NewURLs()
+	u.Suffix = "?html"
+	a.Equal(sanitizeURLs(u).Field, "urls.suffix")
+
+	// 无后缀，文章与资源文件的地址前缀相同
+	u = vars.NewURLs()
+	u.Suffix = ""
+	a.Equal(sanitizeURLs(u).Field, "urls.post")
+	u.Assets = "/assets"
+	a.NotError(sanitizeURLs(u))
+}
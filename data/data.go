@@ -16,36 +16,48 @@ import (
 
 // Data 结构体包含了数据目录下所有需要加载的数据内容。
 type Data struct {
-	path    *path.Path
-	Created time.Time
+	path     *path.Path
+	warnings helper.FieldErrors // 不影响数据加载的错误，比如引用了不存在的标签
+	Created  time.Time
 
 	// 直接从 config 中继承过来的变量
-	SiteName string
-	Subtitle string           // 网站副标题
-	Language string           // 语言标记，比如 zh-cmn-Hans
-	URL      string           // 网站的域名
-	Beian    string           // 备案号
-	Uptime   time.Time        // 上线时间
-	PageSize int              // 每页显示的数量
-	Type     string           // 页面的 mime type 类型
-	Icon     *Icon            // 程序默认的图标
-	Menus    []*Link          // 导航菜单
-	Author   *Author          // 默认作者信息
-	License  *Link            // 默认版权信息
-	Pages    map[string]*Page // 各个页面的自定义内容
-	Outdated time.Duration
+	SiteName  string
+	Subtitle  string             // 网站副标题
+	Language  string             // 语言标记，比如 zh-cmn-Hans
+	Languages []string           // 除 Language 之外，文章可使用的其它语言
+	LangPosts map[string][]*Post // 按语言分类的文章，顺序与 Posts 相同
+	URL       string             // 网站的域名
+	Beian     string             // 备案号
+	Uptime    time.Time          // 上线时间
+	PageSize  int                // 每页显示的数量
+	Type      string             // 页面的 mime type 类型
+	Icon      *Icon              // 程序默认的图标
+	Menus     []*Link            // 导航菜单
+	Author    *Author            // 默认作者信息
+	License   *Link              // 默认版权信息
+	Pages     map[string]*Page   // 各个页面的自定义内容
+	Outdated  time.Duration
+	Twitter   string     // Twitter 账号，比如 @caixw
+	URLs      *vars.URLs // 各类页面地址的构成方式
 
 	Tags     []*Tag
+	TagTree  []*Tag // 标签树，只包含顶级标签，下级标签通过 Tag.Children 获取
 	Series   []*Tag
 	Links    []*Link
 	Posts    []*Post
-	Archives []*Archive
-	Theme    *Theme // 当前主题
+	Archives []*Archive // 按 archive.type 指定的方式归档，即 YearArchives 或是 MonthArchives
+	Theme    *Theme     // 当前主题
 
-	Opensearch *Feed
-	Sitemap    *Feed
-	RSS        *Feed
-	Atom       *Feed
+	YearArchives  []*Archive // 按年归档
+	MonthArchives []*Archive // 按月归档
+
+	Redirects []*Redirect // 跳转规则，包含文章的 aliases，已按匹配的优先级排序
+
+	Opensearch   *Feed
+	Sitemap      *Feed
+	SitemapParts []*Feed // 当 Sitemap 为索引文件时，此值为具体的 sitemap 内容
+	RSS          *Feed
+	Atom         *Feed
 }
 
 // Load 函数用于加载一份新的数据。
@@ -55,7 +67,7 @@ func Load(path *path.Path) (*Data, error) {
 		return nil, err
 	}
 
-	tags, err := loadTags(path)
+	tags, err := loadTags(path, conf.URLs)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +77,12 @@ func Load(path *path.Path) (*Data, error) {
 		return nil, err
 	}
 
-	posts, err := loadPosts(path)
+	redirects, err := loadRedirects(path)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := loadPosts(path, conf.URLs)
 	if err != nil {
 		return nil, err
 	}
@@ -74,28 +91,17 @@ func Load(path *path.Path) (*Data, error) {
 	if err != nil {
 		return nil, err
 	}
+	conf.initPages(theme)
 
-	d := &Data{
-		path:    path,
-		Created: time.Now(),
+	d := newData(path, conf)
+	d.Tags = tags
+	d.Links = links
+	d.Posts = posts
+	d.Theme = theme
+	d.Redirects = redirects
 
-		SiteName: conf.Title,
-		Language: conf.Language,
-		Subtitle: conf.Subtitle,
-		URL:      conf.URL,
-		Beian:    conf.Beian,
-		Uptime:   conf.Uptime,
-		PageSize: conf.PageSize,
-		Type:     conf.Type,
-		Icon:     conf.Icon,
-		Menus:    conf.Menus,
-		Pages:    conf.Pages,
-		Outdated: conf.Outdated,
-
-		Tags:  tags,
-		Links: links,
-		Posts: posts,
-		Theme: theme,
+	if err := d.compileTemplate(); err != nil {
+		return nil, err
 	}
 
 	if err := d.sanitize(conf); err != nil {
@@ -109,12 +115,32 @@ func Load(path *path.Path) (*Data, error) {
 	return d, nil
 }
 
-// 对各个数据再次进行检测，主要是一些关联数据的相互初始化
-func (d *Data) sanitize(conf *config) error {
-	if err := d.compileTemplate(); err != nil {
-		return err
+// 根据配置内容生成一个 Data 对象，其它数据需要另外指定。
+func newData(path *path.Path, conf *config) *Data {
+	return &Data{
+		path:    path,
+		Created: time.Now(),
+
+		SiteName:  conf.Title,
+		Language:  conf.Language,
+		Languages: conf.Languages,
+		Subtitle:  conf.Subtitle,
+		URL:       conf.URL,
+		Beian:     conf.Beian,
+		Uptime:    conf.Uptime,
+		PageSize:  conf.PageSize,
+		Type:      conf.Type,
+		Icon:      conf.Icon,
+		Menus:     conf.Menus,
+		Pages:     conf.Pages,
+		Outdated:  conf.Outdated,
+		Twitter:   conf.Twitter,
+		URLs:      conf.URLs,
 	}
+}
 
+// 对各个数据再次进行检测，主要是一些关联数据的相互初始化
+func (d *Data) sanitize(conf *config) error {
 	p := conf.Pages[vars.PageTag]
 	for _, tag := range d.Tags {
 		// 将标签的默认修改时间设置为网站的上线时间
@@ -123,6 +149,9 @@ func (d *Data) sanitize(conf *config) error {
 		tag.HTMLTitle = helper.ReplaceContent(p.Title, tag.Title)
 	}
 
+	// 一次性报告所有的错误，出错的部分会被忽略，
+	// 但依然会继续之后的处理，以便 Check 可以找出更多的错误。
+	var errs helper.FieldErrors
 	for _, post := range d.Posts {
 		if post.Author == nil {
 			post.Author = conf.Author
@@ -132,9 +161,22 @@ func (d *Data) sanitize(conf *config) error {
 			post.License = conf.License
 		}
 
-		if err := d.attachPostTag(post, conf); err != nil {
-			return err
+		if err := d.attachPostLang(post, conf); err != nil {
+			errs = append(errs, err)
 		}
+
+		errs = append(errs, d.attachPostTag(post, conf)...)
+	}
+
+	if err := d.attachTranslations(); err != nil {
+		errs = append(errs, err)
+	}
+	d.buildLangPosts()
+
+	attachAncestorPosts(d.Posts)
+
+	if err := d.buildSeries(); err != nil {
+		errs = append(errs, err)
 	}
 
 	if d.Outdated == 0 {
@@ -158,36 +200,75 @@ func (d *Data) sanitize(conf *config) error {
 	ts, series := splitTags(tags)
 	d.Tags = ts
 	d.Series = series
+	d.TagTree = buildTagTree(ts)
 
+	buildTagStats(d.Tags)
+	buildTagStats(d.Series)
+
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-// 关联文章与标签的相关信息
-func (d *Data) attachPostTag(post *Post, conf *config) *helper.FieldError {
-	ts := strings.Split(post.TagsString, ",")
-	for _, tag := range d.Tags {
-		for _, slug := range ts {
-			if tag.Slug != slug {
-				continue
-			}
+// 关联文章与标签的相关信息。
+//
+// 文章可以通过标签的 slug 或是别名引用标签；
+// 引用不存在的标签时，若 conf.AutoTags 为 true，则自动创建该标签，
+// 否则忽略该标签，并记录在 d.warnings 中。
+func (d *Data) attachPostTag(post *Post, conf *config) []*helper.FieldError {
+	post.HTMLTitle = helper.ReplaceContent(conf.Pages[vars.PagePost].Title, post.Title)
 
-			post.Tags = append(post.Tags, tag)
-			tag.Posts = append(tag.Posts, post)
+	file := d.path.PostMetaPath(post.Slug)
+	errs := make([]*helper.FieldError, 0, 1)
 
-			if tag.Modified.Before(post.Modified) {
-				tag.Modified = post.Modified
+	for _, slug := range strings.Split(post.TagsString, ",") {
+		slug = strings.TrimSpace(slug)
+		if len(slug) == 0 {
+			continue
+		}
+
+		tag := findTag(d.Tags, slug)
+		if tag == nil {
+			if !conf.AutoTags { // 仅作为警告，不影响数据的加载
+				d.warnings = append(d.warnings, &helper.FieldError{File: file, Message: "不存在的标签：" + slug, Field: "tags"})
+				continue
 			}
-			break
+			tag = d.newAutoTag(slug, conf)
 		}
-	} // end for tags
 
-	post.HTMLTitle = helper.ReplaceContent(conf.Pages[vars.PagePost].Title, post.Title)
+		if post.hasTag(tag) { // 同时引用了标签及其别名
+			continue
+		}
+
+		post.Tags = append(post.Tags, tag)
+		tag.Posts = append(tag.Posts, post)
+
+		if tag.Modified.Before(post.Modified) {
+			tag.Modified = post.Modified
+		}
+	}
 
 	if len(post.Tags) == 0 {
-		return &helper.FieldError{File: d.path.PostMetaPath(post.Slug), Message: "未指定任何关联标签信息", Field: "tags"}
+		errs = append(errs, &helper.FieldError{File: file, Message: "未指定任何关联标签信息", Field: "tags"})
 	}
 
-	return nil
+	return errs
+}
+
+// 创建一个以 slug 作为名称的标签，并添加到 d.Tags 中。
+func (d *Data) newAutoTag(slug string, conf *config) *Tag {
+	tag := &Tag{
+		Slug:     slug,
+		Title:    slug,
+		Content:  slug,
+		Modified: conf.Uptime,
+	}
+	tag.sanitize(d.URLs) // 各字段均不为空，不会返回错误
+	tag.HTMLTitle = helper.ReplaceContent(conf.Pages[vars.PageTag].Title, tag.Title)
+
+	d.Tags = append(d.Tags, tag)
+	return tag
 }
 
 func (d *Data) buildData(conf *config) (err error) {
@@ -199,13 +280,21 @@ func (d *Data) buildData(conf *config) (err error) {
 	}
 
 	errFilter(d.buildArchives)
+	errFilter(d.buildRelated)
 	errFilter(d.buildOpensearch)
 	errFilter(d.buildSitemap)
 	errFilter(d.buildRSS)
 	errFilter(d.buildAtom)
+	errFilter(d.buildRedirects) // 需要所有页面的地址都已经确定
 	return err
 }
 
+// Warnings 返回加载过程中不影响数据使用的错误信息，
+// 比如在未启用 autoTags 时，文章引用了不存在的标签。
+func (d *Data) Warnings() helper.FieldErrors {
+	return d.warnings
+}
+
 // BuildURL 生成一个带域名的地址
 func (d *Data) BuildURL(path string) string {
 	return d.URL + path
@@ -5,9 +5,11 @@
 package data
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/caixw/gitype/path"
+	"github.com/caixw/gitype/vars"
 	"github.com/issue9/assert"
 )
 
@@ -18,7 +20,19 @@ func TestLoad(t *testing.T) {
 	d, err := Load(testdataPath)
 	a.NotError(err).NotNil(d)
 
-	a.Equal(len(d.Posts), 2)
+	a.Equal(len(d.Posts), 3)
+	a.Equal(d.Languages, []string{"en"})
+
+	// tags
+	a.Equal(len(d.TagTree), 1)
+	a.Equal(d.TagTree[0].Slug, "default1")
+	a.Equal(d.TagTree[0].Children[0].Slug, "default2")
+
+	// archives
+	a.Equal(len(d.YearArchives), 1)
+	a.Equal(d.YearArchives[0].Slug, "2016")
+	a.Equal(d.MonthArchives[0].Slug, "2016/01")
+	a.Equal(d.Archives, d.YearArchives)
 
 	// theme
 	a.NotNil(d.Theme)
@@ -28,5 +42,66 @@ func TestLoad(t *testing.T) {
 	// feed
 	a.Equal(d.Opensearch.URL, "/opensearch.xml")
 	a.Equal(d.Atom.URL, "/atom.xml")
+	a.True(bytes.Contains(d.Atom.Content, []byte(`rel="enclosure"`)))
+	a.True(bytes.Contains(d.Atom.Content, []byte("<![CDATA[<article>a1</article>")))
 	a.Nil(d.Sitemap)
 }
+
+func TestData_attachPostTag(t *testing.T) {
+	a := assert.New(t)
+
+	newData := func() *Data {
+		return &Data{
+			path: testdataPath,
+			URLs: vars.NewURLs(),
+			Tags: []*Tag{
+				{Slug: "t1", Title: "t1", Content: "t1"},
+				{Slug: "t2", Title: "t2", Content: "t2", Aliases: []string{"old-t2"}},
+			},
+		}
+	}
+	conf := &config{
+		Pages: map[string]*Page{
+			vars.PagePost: {Title: vars.ContentPlaceholder},
+			vars.PageTag:  {Title: vars.ContentPlaceholder},
+		},
+	}
+
+	// 通过别名引用，且重复引用同一标签
+	d := newData()
+	post := &Post{Slug: "p1", Title: "p1", TagsString: "t1, old-t2,t2"}
+	a.Empty(d.attachPostTag(post, conf))
+	a.Equal(len(post.Tags), 2)
+	a.Equal(post.Tags[1], d.Tags[1])
+	a.Equal(d.Tags[1].Posts[0], post)
+
+	// 不存在的标签，仅作为警告
+	d = newData()
+	post = &Post{Slug: "p1", Title: "p1", TagsString: "t1,not-exists1,not-exists2"}
+	a.Empty(d.attachPostTag(post, conf))
+	a.Equal(len(post.Tags), 1)
+	a.Equal(len(d.Warnings()), 2)
+	a.Equal(d.Warnings()[0].Field, "tags")
+	a.Equal(d.Warnings()[1].Message, "不存在的标签：not-exists2")
+
+	// 只引用了不存在的标签
+	d = newData()
+	post = &Post{Slug: "p1", Title: "p1", TagsString: "not-exists"}
+	a.Equal(len(d.attachPostTag(post, conf)), 1)
+	a.Equal(len(d.Warnings()), 1)
+
+	// 未指定任何标签
+	post = &Post{Slug: "p1", Title: "p1", TagsString: " , "}
+	errs := d.attachPostTag(post, conf)
+	a.Equal(len(errs), 1)
+
+	// 自动创建标签
+	conf.AutoTags = true
+	d = newData()
+	post = &Post{Slug: "p1", Title: "p1", TagsString: "t1,new"}
+	a.Empty(d.attachPostTag(post, conf))
+	a.Equal(len(d.Tags), 3)
+	a.Equal(d.Tags[2].Slug, "new")
+	a.Equal(d.Tags[2].Permalink, vars.NewURLs().TagURL("new", 1))
+	a.Equal(post.Tags[1], d.Tags[2])
+}
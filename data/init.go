@@ -73,7 +73,6 @@ var defaultConfig = &config{
 
 	Pages: map[string]*Page{
 		vars.PageArchives: &Page{
-			Title:    archivesTitle,
 			Keywords: "存档,归档,archive,archives",
 		},
 	},
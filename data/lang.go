@@ -0,0 +1,169 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/path"
+	"github.com/caixw/gitype/vars"
+)
+
+// 文章的语言以及译文的相关处理。
+//
+// 默认语言的文章，地址保持不变；其它语言的文章，地址会加上 /{lang} 前缀。
+func (d *Data) attachPostLang(post *Post, conf *config) *helper.FieldError {
+	if len(post.Lang) == 0 || post.Lang == conf.Language {
+		post.Lang = conf.Language
+		return nil
+	}
+
+	if !inStrings(conf.Languages, post.Lang) {
+		return &helper.FieldError{File: d.path.PostMetaPath(post.Slug), Message: "未在 languages 中定义该语言：" + post.Lang, Field: "lang"}
+	}
+
+	post.Permalink = d.LangURLs(post.Lang).PostURL(post.Slug, post.Created)
+	return nil
+}
+
+// LangURLs 返回语言 lang 下的地址配置，
+// 网站默认语言返回 d.URLs，其它语言的地址会加上 /{lang} 前缀。
+func (d *Data) LangURLs(lang string) *vars.URLs {
+	if lang == d.Language {
+		return d.URLs
+	}
+	return d.URLs.Lang(lang)
+}
+
+// 按语言对文章进行分类。
+//
+// 只有文章详细页和文章列表页区分语言，标签、归档和搜索等页面依然包含所有语言的文章。
+func (d *Data) buildLangPosts() {
+	d.LangPosts = make(map[string][]*Post, len(d.Languages)+1)
+	for _, post := range d.Posts {
+		d.LangPosts[post.Lang] = append(d.LangPosts[post.Lang], post)
+	}
+}
+
+// 关联文章与其译文。
+//
+// 译文关系是相互的，且可以传递：A 指定了 B 为译文，B 指定了 C 为译文，
+// 则 A、B 和 C 互为译文。同一组译文中，不能存在相同语言的文章。
+func (d *Data) attachTranslations() *helper.FieldError {
+	groups := make(map[*Post][]*Post, 10)
+	group := func(p *Post) []*Post {
+		if g, found := groups[p]; found {
+			return g
+		}
+		return []*Post{p}
+	}
+
+	for _, post := range d.Posts {
+		for index, slug := range post.TranslationSlugs {
+			field := "translations[" + strconv.Itoa(index) + "]"
+
+			t := findPost(d.Posts, strings.Trim(slug, "/"))
+			if t == nil {
+				return &helper.FieldError{File: d.path.PostMetaPath(post.Slug), Message: "文章不存在：" + slug, Field: field}
+			}
+
+			g1, g2 := group(post), group(t)
+			if g1[0] == g2[0] { // 已经在同一组中
+				continue
+			}
+
+			merged := append(append(make([]*Post, 0, len(g1)+len(g2)), g1...), g2...)
+			for _, p1 := range g1 {
+				for _, p2 := range g2 {
+					if p1.Lang == p2.Lang {
+						return &helper.FieldError{File: d.path.PostMetaPath(post.Slug), Message: "存在多篇相同语言的译文：" + p1.Slug + "," + p2.Slug, Field: field}
+					}
+				}
+			}
+
+			for _, p := range merged {
+				groups[p] = merged
+			}
+		}
+	}
+
+	for post, g := range groups {
+		post.Translations = make([]*Post, 0, len(g)-1)
+		for _, p := range g {
+			if p != post {
+				post.Translations = append(post.Translations, p)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PostAlternates 获取文章所有语言版本的完整地址，包括文章本身，
+// 每个元素依次为语言和地址，没有译文的返回空值。
+//
+// 可用于输出 hreflang 相关的内容。
+func (d *Data) PostAlternates(post *Post) [][2]string {
+	if len(post.Translations) == 0 {
+		return nil
+	}
+
+	alternates := make([][2]string, 0, len(post.Translations)+1)
+	alternates = append(alternates, [2]string{post.Lang, d.BuildURL(post.Permalink)})
+	for _, t := range post.Translations {
+		alternates = append(alternates, [2]string{t.Lang, d.BuildURL(t.Permalink)})
+	}
+
+	return alternates
+}
+
+// 加载主题下 locales 目录中的界面文字，文件名即为语言名称，比如 locales/en.yaml。
+// 该目录为可选，不存在时返回空值。
+func loadThemeMessages(path *path.Path, id string) (map[string]map[string]string, error) {
+	dir := path.ThemesPath(id, vars.ThemeLocalesDir)
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		lang := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		m := make(map[string]string, 50)
+		if err := helper.LoadYAMLFile(file, &m); err != nil {
+			return nil, err
+		}
+		msgs[lang] = m
+	}
+
+	return msgs, nil
+}
+
+// Message 获取指定语言下 key 对应的界面文字，
+// 找不到时，查找网站默认语言下的内容，依然找不到，则返回 key 本身。
+func (d *Data) Message(lang, key string) string {
+	if msg, found := d.Theme.messages[lang][key]; found {
+		return msg
+	}
+
+	if msg, found := d.Theme.messages[d.Language][key]; found {
+		return msg
+	}
+
+	return key
+}
+
+func inStrings(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/assert"
+)
+
+func TestConfig_sanitize_languages(t *testing.T) {
+	a := assert.New(t)
+
+	newConfig := func(langs ...string) *config {
+		return &config{
+			Language:        "zh-cmn-Hans",
+			Languages:       langs,
+			PageSize:        20,
+			LongDateFormat:  "2006",
+			ShortDateFormat: "2006",
+			UptimeFormat:    "2016-01-02T12:11:01+08:00",
+		}
+	}
+
+	a.Equal(newConfig("en", "").sanitize()[0].Field, "languages[1]")
+	a.Equal(newConfig("en/us").sanitize()[0].Field, "languages[0]")
+	a.Equal(newConfig("zh-cmn-Hans").sanitize()[0].Field, "languages[0]")
+	a.Equal(newConfig("en", "ja", "en").sanitize()[0].Field, "languages[2]")
+}
+
+func TestData_attachPostLang(t *testing.T) {
+	a := assert.New(t)
+	d := &Data{path: testdataPath, URLs: vars.NewURLs()}
+	conf := &config{Language: "zh", Languages: []string{"en"}}
+
+	post := &Post{Slug: "p1", Permalink: "/posts/p1.html"}
+	a.NotError(d.attachPostLang(post, conf))
+	a.Equal(post.Lang, "zh").Equal(post.Permalink, "/posts/p1.html")
+
+	post = &Post{Slug: "p1", Lang: "en", Permalink: "/posts/p1.html"}
+	a.NotError(d.attachPostLang(post, conf))
+	a.Equal(post.Permalink, "/en/posts/p1.html")
+
+	post = &Post{Slug: "p1", Lang: "ja"}
+	a.Equal(d.attachPostLang(post, conf).Field, "lang")
+}
+
+func TestData_attachTranslations(t *testing.T) {
+	a := assert.New(t)
+
+	// 传递关系：p1 -> p2 -> p3
+	p1 := &Post{Slug: "p1", Lang: "zh", TranslationSlugs: []string{"p2"}}
+	p2 := &Post{Slug: "p2", Lang: "en", TranslationSlugs: []string{"p3"}}
+	p3 := &Post{Slug: "p3", Lang: "ja"}
+	p4 := &Post{Slug: "p4", Lang: "zh"}
+	d := &Data{path: testdataPath, Posts: []*Post{p1, p2, p3, p4}}
+	a.NotError(d.attachTranslations())
+	a.Equal(len(p1.Translations), 2)
+	a.Equal(len(p2.Translations), 2)
+	a.Equal(len(p3.Translations), 2)
+	a.Empty(p4.Translations)
+
+	// 相同语言
+	p1 = &Post{Slug: "p1", Lang: "zh", TranslationSlugs: []string{"p2"}}
+	p2 = &Post{Slug: "p2", Lang: "zh"}
+	d = &Data{path: testdataPath, Posts: []*Post{p1, p2}}
+	a.Equal(d.attachTranslations().Field, "translations[0]")
+
+	// 不存在的文章
+	p1 = &Post{Slug: "p1", Lang: "zh", TranslationSlugs: []string{"not-exists"}}
+	d = &Data{path: testdataPath, Posts: []*Post{p1}}
+	a.Equal(d.attachTranslations().Field, "translations[0]")
+}
+
+func TestLoad_translations(t *testing.T) {
+	a := assert.New(t)
+	d, err := Load(testdataPath)
+	a.NotError(err).NotNil(d)
+
+	post1 := findPost(d.Posts, "post1")
+	en := findPost(d.Posts, "post1-en")
+	a.NotNil(post1).NotNil(en)
+	a.Equal(en.Lang, "en").Equal(en.Permalink, "/en/posts/post1-en.html")
+	a.Equal(post1.Lang, d.Language)
+	a.Equal(post1.Translations, []*Post{en})
+	a.Equal(en.Translations, []*Post{post1})
+
+	a.True(bytes.Contains(d.Atom.Content, []byte(`hreflang="en"`)))
+
+	// 界面文字
+	a.Equal(d.Message("en", "read more"), "Read more")
+	a.Equal(d.Message(d.Language, "read more"), "阅读全文")
+	a.Equal(d.Message("ja", "read more"), "阅读全文") // 不存在的语言，使用默认语言
+	a.Equal(d.Message("en", "not-exists"), "not-exists")
+}
+
+func TestData_buildLangPosts(t *testing.T) {
+	a := assert.New(t)
+	d, err := Load(testdataPath)
+	a.NotError(err).NotNil(d)
+
+	en := d.LangPosts["en"]
+	a.Equal(len(en), 1).Equal(en[0].Slug, "post1-en")
+	a.Equal(len(d.LangPosts[d.Language]), len(d.Posts)-1)
+
+	a.Equal(d.LangURLs(d.Language), d.URLs)
+	a.Equal(d.LangURLs("en").PostsURL(1), "/en/")
+}
@@ -0,0 +1,317 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/metrics"
+	"github.com/caixw/gitype/vars"
+)
+
+// 外链检测的默认值
+const (
+	linkCheckConcurrency = 5
+	linkCheckTimeout     = 10 * time.Second
+	linkCheckCacheTTL    = 24 * time.Hour
+)
+
+// BrokenLink 表示一条无效的链接
+type BrokenLink struct {
+	URL     string `json:"url"`              // 链接的原始值
+	File    string `json:"file"`             // 链接所在的文件
+	Field   string `json:"field,omitempty"`  // 链接所在的字段，仅 YAML 文件中的链接有值
+	Line    int    `json:"line,omitempty"`   // 链接所在的行号，0 表示未知
+	Status  int    `json:"status,omitempty"` // 外链返回的状态码，站内链接或是无法访问时为 0
+	Message string `json:"message"`          // 错误信息
+}
+
+// LinkChecker 用于检测文章内容、友情链接、菜单和主题模板中的无效链接。
+//
+// 站内链接直接与路由、raws 目录和文章资源等进行比对，不会发起请求；
+// 外链只在 External 为 true 时才会检测，检测结果会被缓存，
+// 同一个 LinkChecker 实例的多次检测会共享该缓存。
+type LinkChecker struct {
+	External    bool          // 是否检测外链
+	Concurrency int           // 同时检测外链的最大数量
+	CacheTTL    time.Duration // 外链检测结果的缓存时间
+
+	client *http.Client
+	cache  map[string]*linkResult
+	locker sync.Mutex
+}
+
+// 外链的检测结果
+type linkResult struct {
+	status  int
+	message string // 为空表示链接有效
+	checked time.Time
+}
+
+// 一条待检测的链接
+type link struct {
+	url   string
+	base  *url.URL // 相对地址的基准地址
+	file  string
+	field string
+	line  int
+}
+
+// NewLinkChecker 声明一个新的 LinkChecker 实例，
+// concurrency、timeout 和 ttl 为零值时，使用默认值。
+func NewLinkChecker(external bool, concurrency int, timeout, ttl time.Duration) *LinkChecker {
+	if concurrency <= 0 {
+		concurrency = linkCheckConcurrency
+	}
+	if timeout <= 0 {
+		timeout = linkCheckTimeout
+	}
+	if ttl <= 0 {
+		ttl = linkCheckCacheTTL
+	}
+
+	return &LinkChecker{
+		External:    external,
+		Concurrency: concurrency,
+		CacheTTL:    ttl,
+		client:      &http.Client{Timeout: timeout},
+		cache:       make(map[string]*linkResult, 100),
+	}
+}
+
+// Check 检测 d 中所有的链接，返回无效的链接，按文件和行号排序。
+func (c *LinkChecker) Check(d *Data) []*BrokenLink {
+	routes := d.routes()
+	broken := make([]*BrokenLink, 0, 10)
+	external := make([]*link, 0, 100)
+
+	for _, l := range d.collectLinks() {
+		b, ext := d.checkInternalLink(routes, l)
+		switch {
+		case b != nil:
+			broken = append(broken, b)
+		case ext:
+			external = append(external, l)
+		}
+	}
+
+	if c.External {
+		broken = append(broken, c.checkExternal(external)...)
+	}
+
+	sort.SliceStable(broken, func(i, j int) bool {
+		if broken[i].File != broken[j].File {
+			return broken[i].File < broken[j].File
+		}
+		return broken[i].Line < broken[j].Line
+	})
+
+	return broken
+}
+
+// 检测站内链接 l，无效时返回 BrokenLink，l 为外链时 external 返回 true。
+//
+// 除 http 和 https 之外的其它协议、以 // 开头的地址以及页内锚点都被忽略。
+func (d *Data) checkInternalLink(routes map[string]bool, l *link) (broken *BrokenLink, external bool) {
+	u, err := url.Parse(l.url)
+	if err != nil {
+		return l.broken(0, "无效的 URL"), false
+	}
+
+	switch {
+	case u.Scheme == "http" || u.Scheme == "https":
+		return nil, true
+	case u.IsAbs() || len(u.Host) > 0 || len(u.Path) == 0:
+		return nil, false
+	case !d.linkExists(routes, l.base.ResolveReference(u).Path):
+		return l.broken(0, "无效的站内链接"), false
+	}
+
+	return nil, false
+}
+
+// 并发检测外链，同时进行的请求数量不超过 c.Concurrency。
+//
+// 相同的地址只会检测一次，锚点不会发送给服务器，所以不作区分。
+func (c *LinkChecker) checkExternal(links []*link) []*BrokenLink {
+	addrs := make(map[string][]*link, len(links))
+	for _, l := range links {
+		addr := l.url
+		if i := strings.IndexByte(addr, '#'); i >= 0 {
+			addr = addr[:i]
+		}
+		addrs[addr] = append(addrs[addr], l)
+	}
+
+	broken := make([]*BrokenLink, 0, 10)
+	locker := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, c.Concurrency)
+
+	for addr, ls := range addrs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(addr string, ls []*link) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			result := c.probe(addr)
+			if len(result.message) == 0 {
+				return
+			}
+
+			locker.Lock()
+			for _, l := range ls {
+				broken = append(broken, l.broken(result.status, result.message))
+			}
+			locker.Unlock()
+		}(addr, ls)
+	}
+
+	wg.Wait()
+	return broken
+}
+
+// 检测单个外链，优先使用缓存中的结果。
+//
+// 先使用 HEAD 请求，部分服务器不支持 HEAD，此时再使用 GET 请求。
+func (c *LinkChecker) probe(addr string) *linkResult {
+	c.locker.Lock()
+	result, found := c.cache[addr]
+	c.locker.Unlock()
+	if found && time.Now().Sub(result.checked) < c.CacheTTL {
+		metrics.Cache.Inc("linkcheck", metrics.CacheHit)
+		return result
+	}
+	metrics.Cache.Inc("linkcheck", metrics.CacheMiss)
+
+	result = &linkResult{checked: time.Now()}
+	status, err := c.request(http.MethodHead, addr)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.request(http.MethodGet, addr)
+	}
+
+	switch {
+	case err != nil:
+		result.message = err.Error()
+	case status >= 400:
+		result.status = status
+		result.message = strconv.Itoa(status) + " " + http.StatusText(status)
+	default:
+		result.status = status
+	}
+
+	c.locker.Lock()
+	c.cache[addr] = result
+	c.locker.Unlock()
+
+	return result
+}
+
+func (c *LinkChecker) request(method, addr string) (int, error) {
+	req, err := http.NewRequest(method, addr, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", vars.Name+"/"+vars.Version()+" (link checker)")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// 收集所有需要检测的链接，包括文章内容、友情链接、菜单和当前主题的模板。
+func (d *Data) collectLinks() []*link {
+	root, _ := url.Parse(d.URLs.URL("/"))
+	links := make([]*link, 0, 100)
+
+	for _, post := range d.Posts {
+		base, err := url.Parse(post.Permalink)
+		if err != nil {
+			continue
+		}
+		links = appendHTMLLinks(links, post.Content, base, d.path.PostContentPath(post.Slug))
+	}
+
+	for index, l := range d.Links {
+		links = append(links, &link{
+			url:   l.URL,
+			base:  root,
+			file:  d.path.MetaLinksFile,
+			field: "[" + strconv.Itoa(index) + "].url",
+		})
+	}
+
+	for index, l := range d.Menus {
+		links = append(links, &link{
+			url:   l.URL,
+			base:  root,
+			file:  d.path.MetaConfigFile,
+			field: "menus[" + strconv.Itoa(index) + "].url",
+		})
+	}
+
+	templates, _ := filepath.Glob(d.path.ThemesPath(d.Theme.ID, "*"+vars.TemplateExtension))
+	snippets, _ := filepath.Glob(d.path.ThemesPath("*" + vars.TemplateExtension))
+	for _, file := range append(snippets, templates...) {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		for _, l := range appendHTMLLinks(nil, string(content), root, file) {
+			if !strings.Contains(l.url, "{{") { // 忽略由模板生成的地址
+				links = append(links, l)
+			}
+		}
+	}
+
+	return links
+}
+
+// 将 HTML 内容 content 中的所有链接添加到 links 中
+func appendHTMLLinks(links []*link, content string, base *url.URL, file string) []*link {
+	for _, indexes := range urlAttrExpr.FindAllStringSubmatchIndex(content, -1) {
+		links = append(links, &link{
+			url:  content[indexes[4]+1 : indexes[5]-1], // 去掉引号
+			base: base,
+			file: file,
+			line: strings.Count(content[:indexes[0]], "\n") + 1,
+		})
+	}
+
+	return links
+}
+
+func (l *link) broken(status int, message string) *BrokenLink {
+	line := l.line
+	if line == 0 && len(l.field) > 0 {
+		line = helper.FieldLine(l.file, l.field)
+	}
+
+	return &BrokenLink{
+		URL:     l.url,
+		File:    l.file,
+		Field:   l.field,
+		Line:    line,
+		Status:  status,
+		Message: message,
+	}
+}
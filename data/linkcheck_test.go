@@ -0,0 +1,84 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert"
+)
+
+func TestLinkChecker_Check(t *testing.T) {
+	a := assert.New(t)
+	d, err := Load(testdataPath)
+	a.NotError(err).NotNil(d)
+
+	// testdata 中的友情链接和菜单均为无效的相对地址
+	broken := NewLinkChecker(false, 0, 0, 0).Check(d)
+	a.Equal(len(broken), 5)
+	a.Equal(broken[0].File, testdataPath.MetaConfigFile)
+	a.Equal(broken[0].Field, "menus[0].url").Equal(broken[0].URL, "url1")
+	a.True(broken[0].Line > 0)
+	a.Equal(broken[2].File, testdataPath.MetaLinksFile)
+	a.Equal(broken[2].Field, "[0].url")
+}
+
+func TestLinkChecker_external(t *testing.T) {
+	a := assert.New(t)
+
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head": // 不支持 HEAD 请求
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	d, err := Load(testdataPath)
+	a.NotError(err).NotNil(d)
+	d.Links = nil
+	d.Menus = nil
+	d.Posts[0].Content = `<a href="` + srv.URL + `/ok">ok</a>
+<a href="` + srv.URL + `/no-head#anchor">no-head</a>
+<img src="` + srv.URL + `/not-exists.png" />
+<a href="` + srv.URL + `/ok#anchor">ok</a>
+<a href="mailto:test@example.com">mail</a>`
+
+	// 未启用外链检测
+	c := NewLinkChecker(false, 2, time.Second, 0)
+	a.Empty(c.Check(d))
+	a.Equal(atomic.LoadInt32(&hits), 0)
+
+	c.External = true
+	broken := c.Check(d)
+	a.Equal(len(broken), 1)
+	a.Equal(broken[0].URL, srv.URL+"/not-exists.png")
+	a.Equal(broken[0].Status, http.StatusNotFound)
+	a.Equal(broken[0].Line, 3)
+	a.Equal(atomic.LoadInt32(&hits), 4) // ok 被缓存，no-head 请求了两次
+
+	// 使用缓存
+	a.Equal(len(c.Check(d)), 1)
+	a.Equal(atomic.LoadInt32(&hits), 4)
+
+	// 缓存过期
+	c.CacheTTL = time.Nanosecond
+	a.Equal(len(c.Check(d)), 1)
+	a.Equal(atomic.LoadInt32(&hits), 8)
+}
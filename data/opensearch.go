@@ -57,7 +57,7 @@ func (d *Data) buildOpensearch(conf *config) error {
 		"method": http.MethodGet,
 		// 需要全链接，否则 Firefox 的搜索框不认。
 		// https://github.com/caixw/gitype/issues/18
-		"template": d.BuildURL(vars.SearchURL("{searchTerms}", 0)),
+		"template": d.BuildURL(d.URLs.SearchURL("{searchTerms}", 0)),
 	})
 
 	w.WriteElement("Developer", vars.Name, nil)
@@ -70,7 +70,7 @@ func (d *Data) buildOpensearch(conf *config) error {
 		return err
 	}
 	d.Opensearch = &Feed{
-		URL:     o.URL,
+		URL:     d.URLs.URL(o.URL),
 		Type:    o.Type,
 		Title:   o.Title,
 		Content: bs,
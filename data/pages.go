@@ -6,15 +6,17 @@ package data
 
 import "github.com/caixw/gitype/vars"
 
-// 默认标题的定义
-const (
-	tagTitle      = "标签：" + vars.ContentPlaceholder
-	tagsTitle     = "标签"
-	archivesTitle = "归档"
-	searchTitle   = "搜索：" + vars.ContentPlaceholder
-	linksTitle    = "友情链接"
-	postTitle     = vars.ContentPlaceholder
-)
+// 各页面的默认标题，主题可以在 locales 中以 page.{type} 为键名提供其它语言的标题，
+// 比如 page.tags，未提供时使用此处的值。
+var defaultTitles = map[string]string{
+	vars.PageTag:      "标签：" + vars.ContentPlaceholder,
+	vars.PageTags:     "标签",
+	vars.PageArchives: "归档",
+	vars.PageArchive:  "归档：" + vars.ContentPlaceholder,
+	vars.PageSearch:   "搜索：" + vars.ContentPlaceholder,
+	vars.PageLinks:    "友情链接",
+	vars.PagePost:     vars.ContentPlaceholder,
+}
 
 // Page 页面的自定义内容
 type Page struct {
@@ -23,59 +25,31 @@ type Page struct {
 	Description string `yaml:"description"`
 }
 
-func (conf *config) initPages() {
+// 初始化各页面的内容，未指定标题的，使用网站默认语言下的默认标题。
+//
+// 默认标题由主题提供，所以需要在加载主题之后才能调用。
+func (conf *config) initPages(theme *Theme) {
 	if conf.Pages == nil {
 		conf.Pages = make(map[string]*Page, 10)
 	}
 	ps := conf.Pages
 
-	if ps[vars.PageTag] == nil {
-		ps[vars.PageTag] = &Page{}
-	}
-	if ps[vars.PageTags] == nil {
-		ps[vars.PageTags] = &Page{}
-	}
-	if ps[vars.PageArchives] == nil {
-		ps[vars.PageArchives] = &Page{}
-	}
-	if ps[vars.PageSearch] == nil {
-		ps[vars.PageSearch] = &Page{}
-	}
-	if ps[vars.PageLinks] == nil {
-		ps[vars.PageLinks] = &Page{}
-	}
-	if ps[vars.PagePost] == nil {
-		ps[vars.PagePost] = &Page{}
-	}
-	if ps[vars.PagePost] == nil {
-		ps[vars.PagePost] = &Page{}
-	}
-	if ps[vars.PagePosts] == nil {
-		ps[vars.PagePosts] = &Page{}
-	}
-
-	if len(ps[vars.PageTag].Title) == 0 {
-		ps[vars.PageTag].Title = tagTitle
-	}
-
-	if len(ps[vars.PageTags].Title) == 0 {
-		ps[vars.PageTags].Title = tagsTitle
-	}
-
-	if len(ps[vars.PageArchives].Title) == 0 {
-		ps[vars.PageArchives].Title = archivesTitle
-	}
-
-	if len(ps[vars.PageSearch].Title) == 0 {
-		ps[vars.PageSearch].Title = searchTitle
+	for _, typ := range []string{vars.PageTag, vars.PageTags, vars.PageArchives, vars.PageArchive,
+		vars.PageSearch, vars.PageLinks, vars.PagePost, vars.PagePosts} {
+		if ps[typ] == nil {
+			ps[typ] = &Page{}
+		}
 	}
 
-	if len(ps[vars.PageLinks].Title) == 0 {
-		ps[vars.PageLinks].Title = linksTitle
-	}
+	for typ, title := range defaultTitles {
+		if len(ps[typ].Title) > 0 {
+			continue
+		}
 
-	if len(ps[vars.PagePost].Title) == 0 {
-		ps[vars.PagePost].Title = postTitle
+		if msg, found := theme.messages[conf.Language]["page."+typ]; found {
+			title = msg
+		}
+		ps[typ].Title = title
 	}
 
 	suffix := conf.TitleSeparator + conf.Title
@@ -0,0 +1,36 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/assert"
+)
+
+func TestConfig_initPages(t *testing.T) {
+	a := assert.New(t)
+	theme := &Theme{
+		messages: map[string]map[string]string{
+			"en": {"page." + vars.PageTags: "Tags"},
+		},
+	}
+
+	conf := &config{
+		Title:          "title",
+		TitleSeparator: "-",
+		Language:       "en",
+		Pages: map[string]*Page{
+			vars.PageLinks: {Title: "Links"},
+		},
+	}
+	conf.initPages(theme)
+	a.Equal(conf.Pages[vars.PageTags].Title, "Tags-title")   // 主题中的翻译
+	a.Equal(conf.Pages[vars.PageLinks].Title, "Links-title") // 用户指定
+	a.Equal(conf.Pages[vars.PageArchives].Title, "归档-title") // 默认值
+	a.Equal(conf.Pages[vars.PagePosts].Title, "title")       // 没有默认值
+	a.Equal(conf.Pages[vars.PageIndex], conf.Pages[vars.PagePosts])
+}
@@ -7,13 +7,16 @@ package data
 import (
 	"errors"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
 	"github.com/caixw/gitype/path"
 	"github.com/caixw/gitype/vars"
 	"github.com/issue9/utils"
@@ -50,6 +53,33 @@ type Post struct {
 	Order      string    `yaml:"order,omitempty"`    // 排序方式
 	Draft      bool      `yaml:"draft,omitempty"`    // 是否为草稿，为 true，则不会加载该条数据
 
+	// 在专题中的排序，从小到大排列，未指定则排在最后。
+	// 若专题在 tags.yaml 中指定了 parts，则以 parts 为准。
+	SeriesOrder int `yaml:"seriesOrder,omitempty"`
+
+	// 附带的媒体文件，比如播客的音频文件，会同时输出到 RSS 和 Atom 中
+	Enclosures []*Enclosure `yaml:"enclosures,omitempty"`
+
+	// 封面图片，会用于 Open Graph 等元数据中。
+	// 与 Enclosure.URL 相同，非完整的 URL 且不以 / 开头的，表示相对于文章所在的目录。
+	Cover string `yaml:"cover,omitempty"`
+
+	// 文章的语言，未指定则为网站的默认语言，
+	// 其它语言必须在 meta/config.yaml 的 languages 中定义。
+	Lang string `yaml:"lang,omitempty"`
+
+	// 其它语言的译文，值为对应文章的 slug，只需要在其中一篇中指定即可。
+	TranslationSlugs []string `yaml:"translations,omitempty"`
+
+	// 文章的旧地址，访问这些地址时会永久跳转到当前文章。
+	// 以 / 开头的表示完整的地址，否则表示文章原来的 slug。
+	Aliases []string `yaml:"aliases,omitempty"`
+
+	// 以下内容在加载完所有数据之后才计算得出
+	Related      []*Post       `yaml:"-"` // 相关文章，按相关度从高到低排序
+	Series       []*SeriesPart `yaml:"-"` // 所在专题的导航信息
+	Translations []*Post       `yaml:"-"` // 其它语言的译文，不包含当前文章
+
 	// 以下内容不存在时，则会使用全局的默认选项
 	Author   *Author `yaml:"author,omitempty"`   // 作者
 	License  *Link   `yaml:"license,omitempty"`  // 版本信息
@@ -61,6 +91,17 @@ type Post struct {
 	SearchContent string
 }
 
+// Enclosure 表示文章附带的媒体文件
+type Enclosure struct {
+	// 媒体文件的地址，可以是一个完整的 URL，也可以是以 / 开头的站内地址，
+	// 其它情况则表示相对于文章所在目录的地址。
+	URL string `yaml:"url"`
+
+	Length   int64  `yaml:"length"`             // 文件大小，以字节为单位
+	Type     string `yaml:"type"`               // mime type
+	Duration string `yaml:"duration,omitempty"` // 播放时长，格式为 HH:MM:SS，输出到 itunes:duration
+}
+
 // Outdated 表示每一篇文章的过时情况
 type Outdated struct {
 	Type string
@@ -70,7 +111,37 @@ type Outdated struct {
 	Content string // 自定义的提示内容
 }
 
-func loadPosts(path *path.Path) ([]*Post, error) {
+func loadPosts(path *path.Path, urls *vars.URLs) ([]*Post, error) {
+	slugs, err := findPostSlugs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 开始加载文章的具体内容。
+	posts := make([]*Post, 0, len(slugs))
+	for _, slug := range slugs {
+		post, err := loadPost(path, urls, slug)
+		if err != nil {
+			return nil, err
+		}
+
+		if !post.Draft {
+			posts = append(posts, post)
+		}
+	}
+
+	if err := checkPostsDup(posts); err != nil {
+		return nil, err
+	}
+
+	sortPosts(posts)
+
+	return posts, nil
+}
+
+// 查找 data/posts 目录下所有的文章，
+// 同时包含 meta.yaml 和 content.html 的目录即为一篇文章。
+func findPostSlugs(path *path.Path) ([]string, error) {
 	dir := path.PostsDir
 	slugs := make([]string, 0, 100)
 
@@ -99,29 +170,10 @@ func loadPosts(path *path.Path) ([]*Post, error) {
 		return nil, err
 	}
 
-	// 开始加载文章的具体内容。
-	posts := make([]*Post, 0, len(slugs))
-	for _, slug := range slugs {
-		post, err := loadPost(path, slug)
-		if err != nil {
-			return nil, err
-		}
-
-		if !post.Draft {
-			posts = append(posts, post)
-		}
-	}
-
-	if err := checkPostsDup(posts); err != nil {
-		return nil, err
-	}
-
-	sortPosts(posts)
-
-	return posts, nil
+	return slugs, nil
 }
 
-func loadPost(path *path.Path, slug string) (*Post, error) {
+func loadPost(path *path.Path, urls *vars.URLs, slug string) (*Post, error) {
 	post := &Post{}
 	if err := helper.LoadYAMLFile(path.PostMetaPath(slug), post); err != nil {
 		return nil, err
@@ -142,7 +194,7 @@ func loadPost(path *path.Path, slug string) (*Post, error) {
 	post.Created = created
 
 	// permalink
-	post.Permalink = vars.PostURL(post.Slug)
+	post.Permalink = urls.PostURL(post.Slug, post.Created)
 
 	// modified
 	// HTMLTitle 还用作其它功能，需要首先解析其值
@@ -184,7 +236,7 @@ func loadPost(path *path.Path, slug string) (*Post, error) {
 	if len(data) == 0 {
 		return nil, &helper.FieldError{File: path.PostMetaPath(slug), Message: "不能为空", Field: "content"}
 	}
-	post.Content = string(data)
+	post.Content = baseURLs(urls, string(data))
 
 	if len(post.Title) == 0 {
 		return nil, &helper.FieldError{File: path.PostMetaPath(slug), Message: "不能为空", Field: "title"}
@@ -213,12 +265,81 @@ func loadPost(path *path.Path, slug string) (*Post, error) {
 		return nil, &helper.FieldError{File: path.PostMetaPath(slug), Message: "无效的值", Field: "order"}
 	}
 
+	if post.SeriesOrder < 0 {
+		return nil, &helper.FieldError{File: path.PostMetaPath(slug), Message: "不能小于 0", Field: "seriesOrder"}
+	}
+
+	// aliases
+	for index, alias := range post.Aliases {
+		if len(strings.Trim(alias, "/")) == 0 {
+			return nil, &helper.FieldError{File: path.PostMetaPath(slug), Message: "不能为空", Field: "aliases[" + strconv.Itoa(index) + "]"}
+		}
+	}
+
+	// cover
+	if len(post.Cover) > 0 {
+		u, err := url.Parse(post.Cover)
+		if err != nil {
+			return nil, &helper.FieldError{File: path.PostMetaPath(slug), Message: err.Error(), Field: "cover"}
+		}
+		if !u.IsAbs() {
+			post.Cover = postAssetURL(urls, slug, post.Cover)
+		}
+	}
+
+	// enclosures
+	for index, enclosure := range post.Enclosures {
+		if err := enclosure.sanitize(urls, slug); err != nil {
+			err.File = path.PostMetaPath(slug)
+			err.Field = "enclosures[" + strconv.Itoa(index) + "]." + err.Field
+			return nil, err
+		}
+	}
+
 	post.SearchContent = strings.ToLower(post.Content)
 	post.SearchTitle = strings.ToLower(post.Title)
 
 	return post, nil
 }
 
+func (e *Enclosure) sanitize(urls *vars.URLs, slug string) *helper.FieldError {
+	if len(e.URL) == 0 {
+		return &helper.FieldError{Message: "不能为空", Field: "url"}
+	}
+
+	if e.Length < 0 {
+		return &helper.FieldError{Message: "不能小于 0", Field: "length"}
+	}
+
+	if len(e.Type) == 0 {
+		return &helper.FieldError{Message: "不能为空", Field: "type"}
+	}
+
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return &helper.FieldError{Message: err.Error(), Field: "url"}
+	}
+
+	if !u.IsAbs() {
+		e.URL = postAssetURL(urls, slug, e.URL)
+	}
+
+	return nil
+}
+
+// 将相对于文章目录的地址，转换成以 / 开头的站内地址，
+// 已经以 / 开头的地址，表示相对于 urls.base 的地址，以 // 开头的则原样返回。
+func postAssetURL(urls *vars.URLs, slug, addr string) string {
+	if strings.HasPrefix(addr, "//") {
+		return addr
+	}
+	if len(addr) > 0 && addr[0] == '/' {
+		return urls.URL(addr)
+	}
+
+	return urls.AssetURL(strings.Trim(slug, "/") + "/" + addr)
+}
+
 // 检测是否存在同名的文章
 func checkPostsDup(posts []*Post) error {
 	count := func(slug string) (cnt int) {
@@ -232,7 +353,7 @@ func checkPostsDup(posts []*Post) error {
 
 	for _, post := range posts {
 		if count(post.Slug) > 1 {
-			return errors.New("存在同名的文章：" + post.Slug)
+			return errors.New(locale.Translate("存在同名的文章：" + post.Slug))
 		}
 	}
 
@@ -28,19 +28,22 @@ func TestCheckPostsDup(t *testing.T) {
 func TestLoadPost(t *testing.T) {
 	a := assert.New(t)
 
-	post, err := loadPost(testdataPath, "/post1")
+	post, err := loadPost(testdataPath, vars.NewURLs(), "/post1")
 	a.NotError(err).NotNil(post)
 	a.Equal(len(post.Tags), 0) // 未调用 Data.sanitize 初始化
 	a.False(post.Modified.IsZero())
 	a.Equal(post.Template, vars.PagePost)
 	a.Equal(post.Content, "<article>a1</article>\n")
 
-	post, err = loadPost(testdataPath, "/folder/post2")
+	post, err = loadPost(testdataPath, vars.NewURLs(), "/folder/post2")
 	a.NotError(err).NotNil(post)
 	a.Equal(post.Slug, "/folder/post2")
 	a.Equal(post.Template, "t1post") // 模板
+	a.Equal(post.Cover, "/posts/folder/post2/assets/image.svg")
+	a.Equal(len(post.Enclosures), 1)
+	a.Equal(post.Enclosures[0].URL, "/posts/folder/post2/assets/assets.txt")
 
-	post, err = loadPost(testdataPath, "/draft")
+	post, err = loadPost(testdataPath, vars.NewURLs(), "/draft")
 	a.NotError(err).NotNil(post)
 	a.True(post.Draft)
 }
@@ -48,7 +51,48 @@ func TestLoadPost(t *testing.T) {
 func TestLoadPosts(t *testing.T) {
 	a := assert.New(t)
 
-	posts, err := loadPosts(testdataPath)
+	posts, err := loadPosts(testdataPath, vars.NewURLs())
 	a.NotError(err).NotNil(posts)
-	a.Equal(len(posts), 2) // 只有两条记录，Draft=true 的没有被加载
+	a.Equal(len(posts), 3) // 只有三条记录，Draft=true 的没有被加载
+}
+
+func TestEnclosure_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	e := &Enclosure{}
+	a.Error(e.sanitize(vars.NewURLs(), "post"))
+
+	e.URL = "1.mp3"
+	e.Length = -1
+	a.Error(e.sanitize(vars.NewURLs(), "post"))
+
+	e.Length = 1024
+	a.Error(e.sanitize(vars.NewURLs(), "post")) // type 为空
+
+	e.Type = "audio/mpeg"
+	a.NotError(e.sanitize(vars.NewURLs(), "/2017/post"))
+	a.Equal(e.URL, "/posts/2017/post/1.mp3")
+
+	e.URL = "https://example.com/1.mp3"
+	a.NotError(e.sanitize(vars.NewURLs(), "/2017/post"))
+	a.Equal(e.URL, "https://example.com/1.mp3")
+
+	e.URL = "/raws/1.mp3"
+	a.NotError(e.sanitize(vars.NewURLs(), "/2017/post"))
+	a.Equal(e.URL, "/raws/1.mp3")
+}
+
+func TestPostAssetURL(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(postAssetURL(vars.NewURLs(), "/2017/post", "1.png"), "/posts/2017/post/1.png")
+	a.Equal(postAssetURL(vars.NewURLs(), "2017/post", "assets/1.png"), "/posts/2017/post/assets/1.png")
+	a.Equal(postAssetURL(vars.NewURLs(), "2017/post", "/1.png"), "/1.png")
+	a.Equal(postAssetURL(vars.NewURLs(), "2017/post", "//example.com/1.png"), "//example.com/1.png")
+
+	urls := vars.NewURLs()
+	urls.Base = "/blog"
+	a.Equal(postAssetURL(urls, "2017/post", "/1.png"), "/blog/1.png")
+	a.Equal(postAssetURL(urls, "2017/post", "1.png"), "/blog/posts/2017/post/1.png")
+	a.Equal(postAssetURL(urls, "2017/post", "//example.com/1.png"), "//example.com/1.png")
 }
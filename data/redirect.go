@@ -0,0 +1,297 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/path"
+	"github.com/issue9/utils"
+)
+
+// 前缀匹配的通配符
+const redirectWildcard = "*"
+
+// Redirect 表示一条跳转规则
+//
+// 跳转规则只对不存在的地址起作用，所以不能与已有的页面地址相同。
+type Redirect struct {
+	// 需要跳转的地址，以 * 结尾的表示前缀匹配，比如 /posts/2016/*
+	From string `yaml:"from"`
+
+	// 跳转的目标地址，可以是完整的 URL。
+	// 若 From 为前缀匹配，且 To 也以 * 结尾，则 From 中 * 所匹配的内容会替换 To 中的 *，
+	// 比如 /posts/2016/* 跳转到 /archive/*，那么 /posts/2016/a.html 会跳转到 /archive/a.html
+	To string `yaml:"to"`
+
+	// 状态码，只能是 301 或是 302，默认为 301
+	Status int `yaml:"status,omitempty"`
+
+	// 规则的来源，用于输出错误信息
+	file  string
+	field string
+}
+
+// meta/redirects.yaml 为可选文件，不存在时，返回空列表。
+//
+// 出错时，依然会返回格式正确的规则，以便 Check 可以继续检测其它数据。
+func loadRedirects(path *path.Path) ([]*Redirect, error) {
+	redirects := make([]*Redirect, 0, 10)
+	if !utils.FileExists(path.MetaRedirectsFile) {
+		return redirects, nil
+	}
+
+	if err := helper.LoadYAMLFile(path.MetaRedirectsFile, &redirects); err != nil {
+		return nil, err
+	}
+
+	var errs helper.FieldErrors
+	valid := redirects[:0]
+	for index, r := range redirects {
+		r.file = path.MetaRedirectsFile
+		r.field = "[" + strconv.Itoa(index) + "]"
+
+		if err := r.sanitize(); err != nil {
+			err.File = r.file
+			err.Field = r.field + "." + err.Field
+			errs = append(errs, err)
+			continue
+		}
+		valid = append(valid, r)
+	}
+
+	if len(errs) > 0 {
+		return valid, errs
+	}
+	return valid, nil
+}
+
+func (r *Redirect) sanitize() *helper.FieldError {
+	if len(r.From) == 0 || r.From[0] != '/' {
+		return &helper.FieldError{Message: "必须以 / 开头", Field: "from"}
+	}
+
+	if strings.Count(r.From, redirectWildcard) > 1 ||
+		(strings.Contains(r.From, redirectWildcard) && !r.isPrefix()) {
+		return &helper.FieldError{Message: "通配符 * 只能出现在最后", Field: "from"}
+	}
+
+	if len(r.To) == 0 {
+		return &helper.FieldError{Message: "不能为空", Field: "to"}
+	}
+
+	if strings.HasSuffix(r.To, redirectWildcard) && !r.isPrefix() {
+		return &helper.FieldError{Message: "只有前缀匹配的规则才能以 * 结尾", Field: "to"}
+	}
+
+	if r.Status == 0 {
+		r.Status = http.StatusMovedPermanently
+	} else if r.Status != http.StatusMovedPermanently && r.Status != http.StatusFound {
+		return &helper.FieldError{Message: "只能是 301 或是 302", Field: "status"}
+	}
+
+	return nil
+}
+
+func (r *Redirect) isPrefix() bool {
+	return strings.HasSuffix(r.From, redirectWildcard)
+}
+
+// 判断 path 是否与当前规则匹配，若匹配，返回跳转的目标地址。
+func (r *Redirect) match(path string) (string, bool) {
+	if !r.isPrefix() {
+		return r.To, path == r.From
+	}
+
+	prefix := strings.TrimSuffix(r.From, redirectWildcard)
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+
+	if strings.HasSuffix(r.To, redirectWildcard) {
+		return strings.TrimSuffix(r.To, redirectWildcard) + path[len(prefix):], true
+	}
+	return r.To, true
+}
+
+// Redirect 查找与 path 相匹配的跳转规则，返回跳转的目标地址和状态码。
+//
+// 精确匹配的规则优先于前缀匹配，前缀匹配中，前缀越长越优先。
+func (d *Data) Redirect(path string) (url string, status int, found bool) {
+	return findRedirect(d.Redirects, path)
+}
+
+// redirects 需要已经按优先级排序
+func findRedirect(redirects []*Redirect, path string) (url string, status int, found bool) {
+	for _, r := range redirects {
+		if url, found := r.match(path); found {
+			return url, r.Status, true
+		}
+	}
+
+	return "", 0, false
+}
+
+// 将文章的 aliases 转换成跳转规则，并与 meta/redirects.yaml 中的规则合并，
+// 之后检测规则之间是否存在冲突或是循环跳转。
+//
+// 需要在所有页面的地址都已经确定之后调用。
+func (d *Data) buildRedirects(conf *config) error {
+	// meta/redirects.yaml 中的地址均相对于 urls.base
+	redirects := d.Redirects
+	for _, r := range redirects {
+		r.From = d.URLs.URL(r.From)
+		if r.To[0] == '/' && !strings.HasPrefix(r.To, "//") {
+			r.To = d.URLs.URL(r.To)
+		}
+	}
+
+	for _, post := range d.Posts {
+		for index, alias := range post.Aliases {
+			from := d.URLs.URL(alias)
+			if alias[0] != '/' {
+				from = d.URLs.PostURL(alias, post.Created)
+			}
+
+			redirects = append(redirects, &Redirect{
+				From:   from,
+				To:     post.Permalink,
+				Status: http.StatusMovedPermanently,
+				file:   d.path.PostMetaPath(post.Slug),
+				field:  "aliases[" + strconv.Itoa(index) + "]",
+			})
+		}
+	}
+
+	sort.SliceStable(redirects, func(i, j int) bool {
+		ri, rj := redirects[i], redirects[j]
+		switch {
+		case ri.isPrefix() && rj.isPrefix():
+			return len(ri.From) > len(rj.From)
+		default:
+			return !ri.isPrefix() && rj.isPrefix()
+		}
+	})
+
+	if err := checkRedirects(redirects, d.routes()); err != nil {
+		return err
+	}
+
+	d.Redirects = redirects
+	return nil
+}
+
+// 检测跳转规则之间是否存在冲突或是循环跳转，routes 为已有页面的地址。
+func checkRedirects(redirects []*Redirect, routes map[string]bool) *helper.FieldError {
+	froms := make(map[string]bool, len(redirects))
+	for _, r := range redirects {
+		if froms[r.From] {
+			return &helper.FieldError{File: r.file, Field: r.field, Message: "重复的跳转规则：" + r.From}
+		}
+		froms[r.From] = true
+
+		if !r.isPrefix() {
+			if routes[r.From] {
+				return &helper.FieldError{File: r.file, Field: r.field, Message: "与已有的页面地址冲突：" + r.From}
+			}
+			continue
+		}
+
+		// 前缀规则的目标地址若以自身前缀开头，则每次跳转都会再次匹配自身。
+		prefix := strings.TrimSuffix(r.From, redirectWildcard)
+		if strings.HasPrefix(r.To, prefix) {
+			return &helper.FieldError{File: r.file, Field: r.field, Message: "存在循环跳转：" + r.From}
+		}
+
+		for route := range routes {
+			if strings.HasPrefix(route, prefix) {
+				return &helper.FieldError{File: r.file, Field: r.field, Message: "与已有的页面地址冲突：" + route}
+			}
+		}
+	}
+
+	// 前缀规则以 From 本身作为测试地址，* 也会被当作普通字符进行匹配。
+	//
+	// 不存在循环的情况下，最多只会经过 len(redirects) 次跳转，
+	// 超过此数值依然能找到跳转规则的，也视为循环跳转。
+	for _, r := range redirects {
+		path := r.From
+		visited := map[string]bool{path: true}
+
+		for hops := 0; ; hops++ {
+			if hops > len(redirects) {
+				return &helper.FieldError{File: r.file, Field: r.field, Message: "存在循环跳转：" + r.From}
+			}
+
+			url, _, found := findRedirect(redirects, path)
+			if !found || len(url) == 0 || url[0] != '/' { // 不再跳转或是跳转到站外
+				break
+			}
+
+			if index := strings.IndexByte(url, '?'); index >= 0 {
+				url = url[:index]
+			}
+
+			if routes[url] { // 跳转到已有的页面
+				break
+			}
+
+			if visited[url] {
+				return &helper.FieldError{File: r.file, Field: r.field, Message: "存在循环跳转：" + r.From}
+			}
+			visited[url] = true
+			path = url
+		}
+	}
+
+	return nil
+}
+
+// 所有已知页面的地址，不包含查询参数
+func (d *Data) routes() map[string]bool {
+	urls := d.URLs
+	routes := map[string]bool{
+		urls.PostsURL(1):      true,
+		urls.IndexURL(1):      true,
+		urls.TagsURL():        true,
+		urls.LinksURL():       true,
+		urls.ArchivesURL():    true,
+		urls.SearchURL("", 1): true,
+	}
+
+	for _, lang := range d.Languages {
+		routes[urls.Lang(lang).PostsURL(1)] = true
+		routes[urls.Lang(lang).IndexURL(1)] = true
+	}
+
+	for _, post := range d.Posts {
+		routes[post.Permalink] = true
+	}
+
+	for _, tags := range [][]*Tag{d.Tags, d.Series} {
+		for _, tag := range tags {
+			routes[tag.Permalink] = true
+		}
+	}
+
+	for _, archives := range d.detailArchives() {
+		for _, archive := range archives {
+			routes[archive.Permalink] = true
+		}
+	}
+
+	feeds := append([]*Feed{d.RSS, d.Atom, d.Sitemap, d.Opensearch}, d.SitemapParts...)
+	for _, feed := range feeds {
+		if feed != nil {
+			routes[feed.URL] = true
+		}
+	}
+
+	return routes
+}
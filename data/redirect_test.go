@@ -0,0 +1,140 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/assert"
+)
+
+func TestLoadRedirects(t *testing.T) {
+	a := assert.New(t)
+
+	redirects, err := loadRedirects(testdataPath)
+	a.NotError(err).Equal(len(redirects), 2)
+	a.Equal(redirects[0].Status, http.StatusMovedPermanently)
+	a.Equal(redirects[1].Status, http.StatusFound)
+}
+
+func TestRedirect_sanitize(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Redirect{From: "old.html", To: "/new.html"}
+	a.Equal(r.sanitize().Field, "from")
+
+	r = &Redirect{From: "/old/*/abc", To: "/new.html"}
+	a.Equal(r.sanitize().Field, "from")
+
+	r = &Redirect{From: "/old.html"}
+	a.Equal(r.sanitize().Field, "to")
+
+	r = &Redirect{From: "/old.html", To: "/new/*"}
+	a.Equal(r.sanitize().Field, "to")
+
+	r = &Redirect{From: "/old.html", To: "/new.html", Status: 200}
+	a.Equal(r.sanitize().Field, "status")
+
+	r = &Redirect{From: "/old/*", To: "https://example.com/*"}
+	a.NotError(r.sanitize())
+	a.Equal(r.Status, http.StatusMovedPermanently)
+}
+
+func TestFindRedirect(t *testing.T) {
+	a := assert.New(t)
+
+	redirects := []*Redirect{
+		{From: "/old/a.html", To: "/a.html", Status: http.StatusFound},
+		{From: "/old/posts/*", To: "/posts/*", Status: http.StatusMovedPermanently},
+		{From: "/old/*", To: "/", Status: http.StatusMovedPermanently},
+	}
+
+	url, status, found := findRedirect(redirects, "/old/a.html")
+	a.True(found).Equal(url, "/a.html").Equal(status, http.StatusFound)
+
+	url, _, found = findRedirect(redirects, "/old/posts/2016/about.html")
+	a.True(found).Equal(url, "/posts/2016/about.html")
+
+	url, _, found = findRedirect(redirects, "/old/b.html")
+	a.True(found).Equal(url, "/")
+
+	_, _, found = findRedirect(redirects, "/new/b.html")
+	a.False(found)
+}
+
+func TestCheckRedirects(t *testing.T) {
+	a := assert.New(t)
+	routes := map[string]bool{"/": true, "/posts/1.html": true}
+
+	// 跳转到已有的页面
+	redirects := []*Redirect{
+		{From: "/1.html", To: "/2.html"},
+		{From: "/2.html", To: "/posts/1.html"},
+	}
+	a.NotError(checkRedirects(redirects, routes))
+
+	// 与已有页面冲突
+	redirects = []*Redirect{
+		{From: "/posts/1.html", To: "/"},
+	}
+	a.Error(checkRedirects(redirects, routes))
+
+	// 重复的规则
+	redirects = []*Redirect{
+		{From: "/1.html", To: "/"},
+		{From: "/1.html", To: "/posts/1.html"},
+	}
+	a.Error(checkRedirects(redirects, routes))
+
+	// 循环跳转
+	redirects = []*Redirect{
+		{From: "/1.html", To: "/2.html"},
+		{From: "/2.html", To: "/1.html?page=2"},
+	}
+	a.Error(checkRedirects(redirects, routes))
+
+	// 前缀规则的循环跳转
+	redirects = []*Redirect{
+		{From: "/a/*", To: "/b/*"},
+		{From: "/b/*", To: "/a/*"},
+	}
+	a.Error(checkRedirects(redirects, routes))
+
+	// 前缀规则的目标地址以自身前缀开头
+	redirects = []*Redirect{
+		{From: "/a/*", To: "/a/b/*"},
+	}
+	a.Error(checkRedirects(redirects, routes))
+
+	// 前缀规则与已有页面冲突
+	redirects = []*Redirect{
+		{From: "/posts/*", To: "/archives/*"},
+	}
+	a.Error(checkRedirects(redirects, routes))
+
+	// 前缀规则之间间接地形成不断增长的跳转
+	redirects = []*Redirect{
+		{From: "/a/*", To: "/b/x/*"},
+		{From: "/b/*", To: "/a/y/*"},
+	}
+	a.Error(checkRedirects(redirects, routes))
+}
+
+func TestData_buildRedirects(t *testing.T) {
+	a := assert.New(t)
+
+	d, err := Load(testdataPath)
+	a.NotError(err).NotNil(d)
+
+	// 精确匹配的规则排在前面
+	a.Equal(len(d.Redirects), 3)
+	a.Equal(d.Redirects[2].From, "/old/*")
+
+	url, status, found := d.Redirect(vars.NewURLs().PostURL("old/post1", d.Posts[0].Created))
+	a.True(found).Equal(status, http.StatusMovedPermanently)
+	a.Equal(url, "/posts/post1.html")
+}
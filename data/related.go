@@ -0,0 +1,189 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"math"
+	"sort"
+	"unicode"
+)
+
+// 默认的相关文章数量
+const relatedSize = 5
+
+// 计算相关度时，标签和内容相似度各自所占的权重，两者之和为 1。
+const (
+	relatedTagWeight     = 0.6
+	relatedContentWeight = 0.4
+)
+
+// 文章的词频向量
+type termVector struct {
+	terms map[string]float64
+	norm  float64
+}
+
+// 计算每一篇文章的相关文章。
+//
+// 相关度由两部分组成：
+// 共同拥有的标签，标签关联的文章越少，权重越高；
+// 文章内容的相似度，以 TF-IDF 向量的余弦相似度计算。
+func (d *Data) buildRelated(conf *config) error {
+	if len(d.Posts) < 2 {
+		return nil
+	}
+
+	tagWeights := make(map[*Tag]float64, len(d.Tags)+len(d.Series))
+	for _, post := range d.Posts {
+		for _, tag := range post.Tags {
+			if _, found := tagWeights[tag]; !found {
+				tagWeights[tag] = math.Log(1 + float64(len(d.Posts))/float64(len(tag.Posts)))
+			}
+		}
+	}
+
+	vectors := buildTermVectors(d.Posts)
+
+	type scored struct {
+		post  *Post
+		score float64
+	}
+
+	for i, post := range d.Posts {
+		var maxTagScore float64
+		for _, tag := range post.Tags {
+			maxTagScore += tagWeights[tag]
+		}
+
+		list := make([]*scored, 0, len(d.Posts))
+		for j, other := range d.Posts {
+			if i == j {
+				continue
+			}
+
+			var tagScore float64
+			for _, tag := range other.Tags {
+				if post.hasTag(tag) {
+					tagScore += tagWeights[tag]
+				}
+			}
+			if maxTagScore > 0 {
+				tagScore /= maxTagScore
+			}
+
+			score := relatedTagWeight*tagScore +
+				relatedContentWeight*vectors[i].cosine(vectors[j])
+			if score > 0 {
+				list = append(list, &scored{post: other, score: score})
+			}
+		}
+
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].score > list[j].score
+		})
+
+		if len(list) > conf.RelatedSize {
+			list = list[:conf.RelatedSize]
+		}
+
+		post.Related = make([]*Post, 0, len(list))
+		for _, item := range list {
+			post.Related = append(post.Related, item.post)
+		}
+	}
+
+	return nil
+}
+
+func (post *Post) hasTag(tag *Tag) bool {
+	for _, t := range post.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// 为每一篇文章生成 TF-IDF 向量，返回值与 posts 一一对应。
+func buildTermVectors(posts []*Post) []*termVector {
+	counts := make([]map[string]int, 0, len(posts))
+	df := make(map[string]int, 1000)
+
+	for _, post := range posts {
+		terms := tokenize(post.Title + "\n" + stripTags(post.Content))
+		counts = append(counts, terms)
+		for term := range terms {
+			df[term]++
+		}
+	}
+
+	vectors := make([]*termVector, 0, len(posts))
+	for _, terms := range counts {
+		v := &termVector{terms: make(map[string]float64, len(terms))}
+		for term, cnt := range terms {
+			w := float64(cnt) * math.Log(float64(len(posts))/float64(df[term]))
+			if w <= 0 { // 所有文章都包含的词，没有区分度
+				continue
+			}
+			v.terms[term] = w
+			v.norm += w * w
+		}
+		v.norm = math.Sqrt(v.norm)
+		vectors = append(vectors, v)
+	}
+
+	return vectors
+}
+
+// 两个向量的余弦相似度
+func (v *termVector) cosine(v2 *termVector) float64 {
+	if v.norm == 0 || v2.norm == 0 {
+		return 0
+	}
+
+	var dot float64
+	for term, w := range v.terms {
+		dot += w * v2.terms[term]
+	}
+
+	return dot / (v.norm * v2.norm)
+}
+
+// 将文本拆分成词，并统计每个词出现的次数。
+//
+// 拉丁字母等以连续的字母和数字作为一个词，且忽略单个字符的词；
+// 中文等汉字则以相邻的两个字作为一个词。
+func tokenize(text string) map[string]int {
+	terms := make(map[string]int, 100)
+	word := make([]rune, 0, 20)
+	var prev rune // 前一个汉字
+
+	flush := func() {
+		if len(word) > 1 {
+			terms[string(word)]++
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if prev != 0 {
+				terms[string([]rune{prev, r})]++
+			}
+			prev = r
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			prev = 0
+			word = append(word, unicode.ToLower(r))
+		default:
+			flush()
+			prev = 0
+		}
+	}
+	flush()
+
+	return terms
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/issue9/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	a := assert.New(t)
+
+	terms := tokenize("Go 语言的 go test, a")
+	a.Equal(terms["go"], 2)
+	a.Equal(terms["test"], 1)
+	a.Equal(terms["语言"], 1)
+	a.Equal(terms["言的"], 1)
+	_, found := terms["a"] // 单个字母被忽略
+	a.False(found)
+	_, found = terms["的go"]
+	a.False(found)
+}
+
+func TestData_buildRelated(t *testing.T) {
+	a := assert.New(t)
+
+	t1 := &Tag{Slug: "t1"}
+	t2 := &Tag{Slug: "t2"}
+	p1 := &Post{Slug: "p1", Tags: []*Tag{t1, t2}, Content: "golang mux router"}
+	p2 := &Post{Slug: "p2", Tags: []*Tag{t1}, Content: "golang router"}
+	p3 := &Post{Slug: "p3", Tags: []*Tag{t2}, Content: "yaml config"}
+	p4 := &Post{Slug: "p4", Tags: []*Tag{}, Content: "nothing else"}
+	t1.Posts = []*Post{p1, p2}
+	t2.Posts = []*Post{p1, p3}
+
+	d := &Data{
+		Tags:  []*Tag{t1, t2},
+		Posts: []*Post{p1, p2, p3, p4},
+	}
+	a.NotError(d.buildRelated(&config{RelatedSize: 5}))
+
+	a.Equal(p1.Related, []*Post{p2, p3}) // p2 内容更相近
+	a.Equal(p2.Related, []*Post{p1})
+	a.Equal(p3.Related, []*Post{p1})
+	a.Equal(len(p4.Related), 0)
+
+	// 数量限制
+	a.NotError(d.buildRelated(&config{RelatedSize: 1}))
+	a.Equal(p1.Related, []*Post{p2})
+}
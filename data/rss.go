@@ -5,9 +5,15 @@
 package data
 
 import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/vars"
+	"github.com/issue9/is"
 )
 
 const (
@@ -15,12 +21,36 @@ const (
 	contentTypeRSS  = "application/rss+xml"
 )
 
+// 对 rssConfig.Content 可选值的定义
+const (
+	rssContentSummary = "summary" // 只输出摘要
+	rssContentFull    = "full"    // 输出全文
+)
+
 // RSS 和 Atom 相关的配置项
 type rssConfig struct {
 	Title string `yaml:"title"`
 	URL   string `yaml:"url"`
 	Type  string `yaml:"type,omitempty"`
 	Size  int    `yaml:"size"` // 显示数量
+
+	// 输出的内容，可以是 summary 或是 full，默认为 summary。
+	// 输出全文时，文章内容中的相对地址会被转换成绝对地址。
+	Content string `yaml:"content,omitempty"`
+
+	// 播客的相关设置，仅对 RSS 有效。
+	// 指定此值之后，会输出 iTunes 相关的元素。
+	Podcast *podcastConfig `yaml:"podcast,omitempty"`
+}
+
+// iTunes 播客的相关配置
+type podcastConfig struct {
+	Author   string  `yaml:"author,omitempty"`   // 作者，默认为 config.Author.Name
+	Summary  string  `yaml:"summary,omitempty"`  // 描述，默认为 config.Subtitle
+	Image    string  `yaml:"image,omitempty"`    // 封面图片，必须是完整的 URL
+	Category string  `yaml:"category"`           // iTunes 中的分类
+	Explicit bool    `yaml:"explicit,omitempty"` // 是否包含儿童不宜的内容
+	Owner    *Author `yaml:"owner,omitempty"`    // 所有者，默认为 config.Author
 }
 
 // 生成一个符合 RSS 规范的 XML 文本。
@@ -31,26 +61,37 @@ func (d *Data) buildRSS(conf *config) error {
 
 	w := helper.NewWriter()
 
-	w.WriteStartElement("rss", map[string]string{
+	attrs := map[string]string{
 		"version":    "2.0",
 		"xmlns:atom": "http://www.w3.org/2005/Atom",
-	})
+	}
+	if conf.RSS.Content == rssContentFull {
+		attrs["xmlns:content"] = "http://purl.org/rss/1.0/modules/content/"
+	}
+	if conf.RSS.Podcast != nil {
+		attrs["xmlns:itunes"] = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	}
+	w.WriteStartElement("rss", attrs)
 	w.WriteStartElement("channel", nil)
 
 	w.WriteElement("title", conf.Title, nil)
 	w.WriteElement("description", conf.Subtitle, nil)
-	w.WriteElement("link", conf.URL, nil)
+	w.WriteElement("link", d.URL+d.URLs.Base, nil)
 
 	if conf.Opensearch != nil {
 		w.WriteCloseElement("atom:link", map[string]string{
 			"rel":   "search",
 			"type":  conf.Opensearch.Type,
 			"title": conf.Opensearch.Title,
-			"href":  d.BuildURL(conf.Opensearch.URL),
+			"href":  d.BuildURL(d.URLs.URL(conf.Opensearch.URL)),
 		})
 	}
 
-	addPostsToRSS(w, d)
+	if conf.RSS.Podcast != nil {
+		addPodcastToRSS(w, conf.RSS.Podcast)
+	}
+
+	addPostsToRSS(w, d, conf.RSS)
 
 	w.WriteEndElement("channel")
 	w.WriteEndElement("rss")
@@ -61,7 +102,7 @@ func (d *Data) buildRSS(conf *config) error {
 	}
 	d.RSS = &Feed{
 		Title:   conf.RSS.Title,
-		URL:     conf.RSS.URL,
+		URL:     d.URLs.URL(conf.RSS.URL),
 		Type:    conf.RSS.Type,
 		Content: bs,
 	}
@@ -69,7 +110,35 @@ func (d *Data) buildRSS(conf *config) error {
 	return nil
 }
 
-func addPostsToRSS(w *helper.XMLWriter, d *Data) {
+func addPodcastToRSS(w *helper.XMLWriter, podcast *podcastConfig) {
+	w.WriteElement("itunes:author", podcast.Author, nil)
+	w.WriteElement("itunes:summary", podcast.Summary, nil)
+
+	if len(podcast.Image) > 0 {
+		w.WriteCloseElement("itunes:image", map[string]string{
+			"href": podcast.Image,
+		})
+	}
+
+	w.WriteCloseElement("itunes:category", map[string]string{
+		"text": podcast.Category,
+	})
+
+	if podcast.Explicit {
+		w.WriteElement("itunes:explicit", "yes", nil)
+	} else {
+		w.WriteElement("itunes:explicit", "no", nil)
+	}
+
+	w.WriteStartElement("itunes:owner", nil)
+	w.WriteElement("itunes:name", podcast.Owner.Name, nil)
+	if len(podcast.Owner.Email) > 0 {
+		w.WriteElement("itunes:email", podcast.Owner.Email, nil)
+	}
+	w.WriteEndElement("itunes:owner")
+}
+
+func addPostsToRSS(w *helper.XMLWriter, d *Data, rss *rssConfig) {
 	for _, p := range d.Posts {
 		w.WriteStartElement("item", nil)
 
@@ -78,10 +147,97 @@ func addPostsToRSS(w *helper.XMLWriter, d *Data) {
 		w.WriteElement("pubDate", p.Created.Format(time.RFC1123), nil)
 		w.WriteElement("description", p.Summary, nil)
 
+		for _, t := range p.Translations {
+			w.WriteCloseElement("atom:link", map[string]string{
+				"rel":      "alternate",
+				"hreflang": t.Lang,
+				"href":     d.BuildURL(t.Permalink),
+			})
+		}
+
+		if rss.Content == rssContentFull {
+			w.WriteCDATAElement("content:encoded", d.feedContent(p), nil)
+		}
+
+		for _, e := range p.Enclosures {
+			w.WriteCloseElement("enclosure", map[string]string{
+				"url":    d.enclosureURL(e),
+				"length": strconv.FormatInt(e.Length, 10),
+				"type":   e.Type,
+			})
+
+			if rss.Podcast != nil && len(e.Duration) > 0 {
+				w.WriteElement("itunes:duration", e.Duration, nil)
+			}
+		}
+
+		if rss.Podcast != nil && len(p.Enclosures) > 0 {
+			w.WriteElement("itunes:author", p.Author.Name, nil)
+			w.WriteElement("itunes:summary", p.Summary, nil)
+		}
+
 		w.WriteEndElement("item")
 	}
 }
 
+// 匹配 HTML 中表示地址的属性
+var urlAttrExpr = regexp.MustCompile(`(\s(?:src|href|poster)\s*=\s*)("[^"]*"|'[^']*')`)
+
+// 获取用于输出到 feed 中的文章内容，所有的相对地址都被转换成了绝对地址。
+//
+// 文章内容中以 / 开头的地址，在加载时已经加上了 urls.base，参考 baseURLs。
+func (d *Data) feedContent(p *Post) string {
+	return absoluteURLs(d.BuildURL(p.Permalink), p.Content)
+}
+
+// 将 content 中的相对地址替换成以 base 为基准的绝对地址
+func absoluteURLs(base, content string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return content
+	}
+
+	return urlAttrExpr.ReplaceAllStringFunc(content, func(attr string) string {
+		matches := urlAttrExpr.FindStringSubmatch(attr)
+		quote := matches[2][:1]
+		val := matches[2][1 : len(matches[2])-1]
+
+		u, err := url.Parse(val)
+		if err != nil || u.IsAbs() {
+			return attr
+		}
+
+		return matches[1] + quote + b.ResolveReference(u).String() + quote
+	})
+}
+
+// 为 content 中以 / 开头的站内地址加上 urls.base 前缀，
+// 与配置文件中的地址相同，文章内容中以 / 开头的地址也是相对于 urls.base 的。
+func baseURLs(urls *vars.URLs, content string) string {
+	if len(urls.Base) == 0 {
+		return content
+	}
+
+	return urlAttrExpr.ReplaceAllStringFunc(content, func(attr string) string {
+		matches := urlAttrExpr.FindStringSubmatch(attr)
+		quote := matches[2][:1]
+		val := matches[2][1 : len(matches[2])-1]
+
+		if len(val) == 0 || val[0] != '/' || strings.HasPrefix(val, "//") {
+			return attr
+		}
+		return matches[1] + quote + urls.URL(val) + quote
+	})
+}
+
+// 获取媒体文件的完整地址
+func (d *Data) enclosureURL(e *Enclosure) string {
+	if len(e.URL) > 0 && e.URL[0] == '/' {
+		return d.BuildURL(e.URL)
+	}
+	return e.URL
+}
+
 func (rss *rssConfig) sanitize(conf *config, typ string) *helper.FieldError {
 	if rss.Size <= 0 {
 		return &helper.FieldError{Message: "必须大于 0", Field: typ + ".Size"}
@@ -103,5 +259,53 @@ func (rss *rssConfig) sanitize(conf *config, typ string) *helper.FieldError {
 		rss.Title = conf.Title
 	}
 
+	if len(rss.Content) == 0 {
+		rss.Content = rssContentSummary
+	} else if rss.Content != rssContentSummary && rss.Content != rssContentFull {
+		return &helper.FieldError{Message: "取值不正确", Field: typ + ".content"}
+	}
+
+	if rss.Podcast != nil {
+		if typ != "rss" { // atom 并不会输出 iTunes 的相关元素
+			return &helper.FieldError{Message: "仅对 rss 有效", Field: typ + ".podcast"}
+		}
+
+		if err := rss.Podcast.sanitize(conf); err != nil {
+			err.Field = typ + ".podcast." + err.Field
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (podcast *podcastConfig) sanitize(conf *config) *helper.FieldError {
+	if len(podcast.Category) == 0 {
+		return &helper.FieldError{Message: "不能为空", Field: "category"}
+	}
+
+	if len(podcast.Image) > 0 && !is.URL(podcast.Image) {
+		return &helper.FieldError{Message: "不是一个正确的 URL", Field: "image"}
+	}
+
+	if podcast.Owner == nil {
+		podcast.Owner = conf.Author
+	}
+	if podcast.Owner == nil {
+		return &helper.FieldError{Message: "不能为空", Field: "owner"}
+	}
+	if err := podcast.Owner.sanitize(); err != nil {
+		err.Field = "owner." + err.Field
+		return err
+	}
+
+	if len(podcast.Author) == 0 && conf.Author != nil {
+		podcast.Author = conf.Author.Name
+	}
+
+	if len(podcast.Summary) == 0 {
+		podcast.Summary = conf.Subtitle
+	}
+
 	return nil
 }
@@ -7,6 +7,7 @@ package data
 import (
 	"testing"
 
+	"github.com/caixw/gitype/vars"
 	"github.com/issue9/assert"
 )
 
@@ -33,4 +34,54 @@ func TestRSSConfig_sanitize(t *testing.T) {
 	rss.URL = "url"
 	a.NotError(rss.sanitize(conf, "rss"))
 	a.Equal(rss.Title, conf.Title)
+	a.Equal(rss.Content, rssContentSummary)
+
+	// content 错误
+	rss.Content = "xx"
+	a.Error(rss.sanitize(conf, "rss"))
+	rss.Content = rssContentFull
+	a.NotError(rss.sanitize(conf, "rss"))
+
+	// podcast 未指定 category
+	rss.Podcast = &podcastConfig{}
+	a.Error(rss.sanitize(conf, "rss"))
+
+	// podcast 未指定 owner，且 conf.Author 为空
+	rss.Podcast.Category = "Technology"
+	a.Error(rss.sanitize(conf, "rss"))
+
+	conf.Author = &Author{Name: "caixw"}
+	conf.Subtitle = "subtitle"
+	a.NotError(rss.sanitize(conf, "rss"))
+	a.Equal(rss.Podcast.Owner, conf.Author)
+	a.Equal(rss.Podcast.Author, "caixw")
+	a.Equal(rss.Podcast.Summary, "subtitle")
+
+	// atom 不能指定 podcast
+	a.Equal(rss.sanitize(conf, "atom").Field, "atom.podcast")
+}
+
+func TestAbsoluteURLs(t *testing.T) {
+	a := assert.New(t)
+	base := "https://caixw.io/posts/2017/post.html"
+
+	a.Equal(absoluteURLs(base, `<img src="post/1.png" />`), `<img src="https://caixw.io/posts/2017/post/1.png" />`)
+	a.Equal(absoluteURLs(base, `<a href='/tags.html'>`), `<a href='https://caixw.io/tags.html'>`)
+	a.Equal(absoluteURLs(base, `<a href="https://example.com/1.html">`), `<a href="https://example.com/1.html">`)
+	a.Equal(absoluteURLs(base, `<a href="mailto:caixw@example.com">`), `<a href="mailto:caixw@example.com">`)
+	a.Equal(absoluteURLs(base, `<p data-src="1.png">src="1.png"</p>`), `<p data-src="1.png">src="1.png"</p>`)
+}
+
+func TestBaseURLs(t *testing.T) {
+	a := assert.New(t)
+	urls := vars.NewURLs()
+
+	a.Equal(baseURLs(urls, `<a href="/tags.html">`), `<a href="/tags.html">`)
+
+	urls.Base = "/blog"
+	a.Equal(baseURLs(urls, `<a href="/tags.html">`), `<a href="/blog/tags.html">`)
+	a.Equal(baseURLs(urls, `<img src='/1.png' />`), `<img src='/blog/1.png' />`)
+	a.Equal(baseURLs(urls, `<img src="1.png" />`), `<img src="1.png" />`)
+	a.Equal(baseURLs(urls, `<img src="//example.com/1.png" />`), `<img src="//example.com/1.png" />`)
+	a.Equal(baseURLs(urls, `<a href="https://example.com/1.html">`), `<a href="https://example.com/1.html">`)
 }
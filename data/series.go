@@ -0,0 +1,124 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caixw/gitype/helper"
+)
+
+// SeriesPart 表示文章在某一专题中的位置信息
+type SeriesPart struct {
+	Series *Tag    // 所在的专题
+	Part   int     // 当前文章在专题中的序号，从 1 开始
+	Total  int     // 专题中的文章总数
+	Prev   *Post   // 专题中的上一篇，第一篇时为空
+	Next   *Post   // 专题中的下一篇，最后一篇时为空
+	Posts  []*Post // 专题中的所有文章，可以当作目录使用
+}
+
+// 对所有专题中的文章进行排序，并生成每篇文章在专题中的位置信息。
+//
+// 需要在关联文章与标签之后，且在过滤空标签之前调用，
+// 此时 d.Tags 的顺序与 tags.yaml 中的相同，方便输出错误信息。
+func (d *Data) buildSeries() *helper.FieldError {
+	for index, tag := range d.Tags {
+		if err := tag.sortParts(); err != nil {
+			err.File = d.path.MetaTagsFile
+			err.Field = "[" + strconv.Itoa(index) + "]." + err.Field
+			return err
+		}
+
+		if !tag.Series {
+			continue
+		}
+
+		for i, post := range tag.Posts {
+			part := &SeriesPart{
+				Series: tag,
+				Part:   i + 1,
+				Total:  len(tag.Posts),
+				Posts:  tag.Posts,
+			}
+
+			if i > 0 {
+				part.Prev = tag.Posts[i-1]
+			}
+			if i+1 < len(tag.Posts) {
+				part.Next = tag.Posts[i+1]
+			}
+
+			post.Series = append(post.Series, part)
+		}
+	}
+
+	return nil
+}
+
+// 对专题中的文章进行排序。
+//
+// 若指定了 Tag.Parts，则以其指定的顺序为准，未指定的文章按原来的顺序放在最后；
+// 否则以文章的 SeriesOrder 从小到大排序，未指定 SeriesOrder 的放在最后，
+// SeriesOrder 相同的，按创建时间从早到晚排序。
+func (tag *Tag) sortParts() *helper.FieldError {
+	if !tag.Series {
+		if len(tag.Parts) > 0 {
+			return &helper.FieldError{Message: "只有专题才能指定该值", Field: "parts"}
+		}
+		return nil
+	}
+
+	if len(tag.Parts) == 0 {
+		sort.SliceStable(tag.Posts, func(i, j int) bool {
+			pi, pj := tag.Posts[i], tag.Posts[j]
+			switch {
+			case pi.SeriesOrder == pj.SeriesOrder:
+				return pi.Created.Before(pj.Created)
+			case pi.SeriesOrder == 0:
+				return false
+			case pj.SeriesOrder == 0:
+				return true
+			default:
+				return pi.SeriesOrder < pj.SeriesOrder
+			}
+		})
+		return nil
+	}
+
+	posts := make([]*Post, 0, len(tag.Posts))
+	for index, slug := range tag.Parts {
+		post := findPost(tag.Posts, strings.Trim(slug, "/"))
+		if post == nil {
+			return &helper.FieldError{Message: "文章不存在或是未关联该专题", Field: "parts[" + strconv.Itoa(index) + "]"}
+		}
+
+		if findPost(posts, post.Slug) != nil {
+			return &helper.FieldError{Message: "重复的文章", Field: "parts[" + strconv.Itoa(index) + "]"}
+		}
+
+		posts = append(posts, post)
+	}
+
+	for _, post := range tag.Posts {
+		if findPost(posts, post.Slug) == nil {
+			posts = append(posts, post)
+		}
+	}
+	tag.Posts = posts
+
+	return nil
+}
+
+func findPost(posts []*Post, slug string) *Post {
+	for _, post := range posts {
+		if post.Slug == slug {
+			return post
+		}
+	}
+	return nil
+}
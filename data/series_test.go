@@ -0,0 +1,64 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/issue9/assert"
+)
+
+func TestTag_sortParts(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+	p1 := &Post{Slug: "p1", Created: now}
+	p2 := &Post{Slug: "p2", Created: now.Add(-time.Hour)}
+	p3 := &Post{Slug: "p3", Created: now.Add(-2 * time.Hour), SeriesOrder: 2}
+	p4 := &Post{Slug: "p4", Created: now, SeriesOrder: 1}
+
+	// 非专题不能指定 parts
+	tag := &Tag{Posts: []*Post{p1, p2}, Parts: []string{"p1"}}
+	a.Error(tag.sortParts())
+
+	// 按 seriesOrder 和创建时间排序
+	tag = &Tag{Series: true, Posts: []*Post{p1, p2, p3, p4}}
+	a.NotError(tag.sortParts())
+	a.Equal(tag.Posts, []*Post{p4, p3, p2, p1})
+
+	// 按 parts 排序
+	tag = &Tag{Series: true, Posts: []*Post{p1, p2, p3, p4}, Parts: []string{"p2", "/p1"}}
+	a.NotError(tag.sortParts())
+	a.Equal(tag.Posts, []*Post{p2, p1, p3, p4})
+
+	// 不存在的文章
+	tag = &Tag{Series: true, Posts: []*Post{p1, p2}, Parts: []string{"p2", "p3"}}
+	a.Error(tag.sortParts())
+
+	// 重复的文章
+	tag = &Tag{Series: true, Posts: []*Post{p1, p2}, Parts: []string{"p2", "p2"}}
+	a.Error(tag.sortParts())
+}
+
+func TestData_buildSeries(t *testing.T) {
+	a := assert.New(t)
+	p1 := &Post{Slug: "p1", SeriesOrder: 1}
+	p2 := &Post{Slug: "p2", SeriesOrder: 2}
+	p3 := &Post{Slug: "p3", SeriesOrder: 3}
+	series := &Tag{Series: true, Posts: []*Post{p3, p1, p2}}
+	tag := &Tag{Posts: []*Post{p3, p1}}
+
+	d := &Data{path: testdataPath, Tags: []*Tag{tag, series}}
+	a.NotError(d.buildSeries())
+
+	a.Equal(len(p1.Series), 1)
+	part := p2.Series[0]
+	a.Equal(part.Series, series)
+	a.Equal(part.Part, 2).Equal(part.Total, 3)
+	a.Equal(part.Prev, p1).Equal(part.Next, p3)
+	a.Equal(part.Posts, []*Post{p1, p2, p3})
+	a.Nil(p1.Series[0].Prev)
+	a.Nil(p3.Series[0].Next)
+}
@@ -5,15 +5,33 @@
 package data
 
 import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caixw/gitype/helper"
 	"github.com/caixw/gitype/vars"
+	"github.com/issue9/utils"
 )
 
 const contentTypeXML = "application/xml"
 
+// 单个 sitemap 文件中允许的最大 URL 数量，超过此值会被拆分成多个文件，
+// 并生成一个 sitemap 索引文件。
+//
+// 该值由 sitemap 规范规定：https://www.sitemaps.org/protocol.html
+const sitemapMaxURLs = 50000
+
+// 会被当作图片写入 sitemap 中的文件扩展名
+var sitemapImageExts = []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg"}
+
+// 匹配文章内容中的图片地址
+var imgSrcExpr = regexp.MustCompile(`<img\s[^>]*?src\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
 type sitemapConfig struct {
 	URL  string `yaml:"url"`
 	Type string `yaml:"type,omitempty"`
@@ -28,82 +46,343 @@ type sitemapConfig struct {
 	PostChangefreq string  `yaml:"postChangefreq"`
 }
 
+// sitemap 中的一条记录
+type sitemapURL struct {
+	loc        string
+	changefreq string
+	lastmod    time.Time
+	priority   float64
+	images     []string    // 页面中包含的图片，均为完整的地址
+	alternates [][2]string // 其它语言的版本，依次为语言和完整的地址
+}
+
 // 生成一个符合 sitemap 规范的 XML 文本。
+//
+// 当 URL 数量超过 sitemapMaxURLs 时，Data.Sitemap 为一个索引文件，
+// 具体的内容被拆分到 Data.SitemapParts 中。
 func (d *Data) buildSitemap(conf *config) error {
 	if conf.Sitemap == nil {
 		return nil
 	}
 
-	w := helper.NewWriter()
+	urls := make([]*sitemapURL, 0, len(d.Posts)+10)
+	urls = d.appendPostsToSitemap(urls, conf)
+	urls = d.appendPagesToSitemap(urls, conf)
 
-	if len(conf.Sitemap.XslURL) > 0 {
-		w.WritePI("xml-stylesheet", map[string]string{
-			"type": "text/xsl",
-			"href": conf.Sitemap.XslURL,
-		})
+	if conf.Sitemap.EnableTag {
+		urls = d.appendTagsToSitemap(urls, conf)
 	}
 
-	w.WriteStartElement("urlset", map[string]string{
-		"xmlns": "http://www.sitemaps.org/schemas/sitemap/0.9",
-	})
+	return d.buildSitemapFeeds(urls, conf.Sitemap, d.URLs.URL(conf.Sitemap.URL), sitemapMaxURLs)
+}
 
-	addPostsToSitemap(w, d, conf)
+// 将 urls 按每个文件最多 size 条记录，生成相应的 sitemap 文件，
+// url 为 sitemap 的地址，拆分之后的文件地址也由其生成。
+func (d *Data) buildSitemapFeeds(urls []*sitemapURL, conf *sitemapConfig, url string, size int) error {
+	if len(urls) <= size {
+		bs, err := buildSitemapURLSet(urls, conf)
+		if err != nil {
+			return err
+		}
 
-	// archives.html
-	loc := d.BuildURL(vars.ArchivesURL())
-	addItemToSitemap(w, loc, conf.Sitemap.Changefreq, d.Created, conf.Sitemap.Priority)
+		d.Sitemap = &Feed{
+			URL:     url,
+			Type:    conf.Type,
+			Content: bs,
+		}
+		return nil
+	}
 
-	// links.html
-	loc = d.BuildURL(vars.LinksURL())
-	addItemToSitemap(w, loc, conf.Sitemap.Changefreq, d.Created, conf.Sitemap.Priority)
+	parts := make([]*Feed, 0, len(urls)/size+1)
+	for i := 0; i*size < len(urls); i++ {
+		end := (i + 1) * size
+		if end > len(urls) {
+			end = len(urls)
+		}
 
-	if conf.Sitemap.EnableTag {
-		addTagsToSitemap(w, d, conf)
-	}
+		bs, err := buildSitemapURLSet(urls[i*size:end], conf)
+		if err != nil {
+			return err
+		}
 
-	w.WriteEndElement("urlset")
+		parts = append(parts, &Feed{
+			URL:     sitemapPartURL(url, i+1),
+			Type:    conf.Type,
+			Content: bs,
+		})
+	}
 
-	bs, err := w.Bytes()
+	bs, err := d.buildSitemapIndex(parts, conf)
 	if err != nil {
 		return err
 	}
+
 	d.Sitemap = &Feed{
-		URL:     conf.Sitemap.URL,
-		Type:    conf.Sitemap.Type,
+		URL:     url,
+		Type:    conf.Type,
 		Content: bs,
 	}
+	d.SitemapParts = parts
 
 	return nil
 }
 
-func addPostsToSitemap(w *helper.XMLWriter, d *Data, conf *config) {
+// 根据索引文件的地址生成第 index 个子文件的地址，
+// 比如 /sitemap.xml 的第一个子文件为 /sitemap-1.xml
+func sitemapPartURL(url string, index int) string {
+	ext := filepath.Ext(url)
+	return strings.TrimSuffix(url, ext) + "-" + strconv.Itoa(index) + ext
+}
+
+func writeSitemapXsl(w *helper.XMLWriter, conf *sitemapConfig) {
+	if len(conf.XslURL) > 0 {
+		w.WritePI("xml-stylesheet", map[string]string{
+			"type": "text/xsl",
+			"href": conf.XslURL,
+		})
+	}
+}
+
+func buildSitemapURLSet(urls []*sitemapURL, conf *sitemapConfig) ([]byte, error) {
+	w := helper.NewWriter()
+
+	writeSitemapXsl(w, conf)
+
+	w.WriteStartElement("urlset", map[string]string{
+		"xmlns":       "http://www.sitemaps.org/schemas/sitemap/0.9",
+		"xmlns:image": "http://www.google.com/schemas/sitemap-image/1.1",
+		"xmlns:xhtml": "http://www.w3.org/1999/xhtml",
+	})
+
+	for _, u := range urls {
+		addItemToSitemap(w, u)
+	}
+
+	w.WriteEndElement("urlset")
+
+	return w.Bytes()
+}
+
+func (d *Data) buildSitemapIndex(parts []*Feed, conf *sitemapConfig) ([]byte, error) {
+	w := helper.NewWriter()
+
+	writeSitemapXsl(w, conf)
+
+	w.WriteStartElement("sitemapindex", map[string]string{
+		"xmlns": "http://www.sitemaps.org/schemas/sitemap/0.9",
+	})
+
+	for _, part := range parts {
+		w.WriteStartElement("sitemap", nil)
+		w.WriteElement("loc", d.BuildURL(part.URL), nil)
+		w.WriteElement("lastmod", d.Created.Format(time.RFC3339), nil)
+		w.WriteEndElement("sitemap")
+	}
+
+	w.WriteEndElement("sitemapindex")
+
+	return w.Bytes()
+}
+
+func (d *Data) appendPostsToSitemap(urls []*sitemapURL, conf *config) []*sitemapURL {
 	sitemap := conf.Sitemap
 	for _, p := range d.Posts {
-		loc := d.BuildURL(p.Permalink)
-		addItemToSitemap(w, loc, sitemap.PostChangefreq, p.Modified, sitemap.PostPriority)
+		urls = append(urls, &sitemapURL{
+			loc:        d.BuildURL(p.Permalink),
+			changefreq: sitemap.PostChangefreq,
+			lastmod:    p.Modified,
+			priority:   sitemap.PostPriority,
+			images:     d.postImages(p),
+			alternates: d.PostAlternates(p),
+		})
+	}
+
+	return urls
+}
+
+// 文章列表页、归档页和友情链接页
+func (d *Data) appendPagesToSitemap(urls []*sitemapURL, conf *config) []*sitemapURL {
+	sitemap := conf.Sitemap
+
+	// 各语言首页之后的文章列表页
+	for _, lang := range append([]string{d.Language}, d.Languages...) {
+		posts := d.LangPosts[lang]
+		for page := 2; (page-1)*d.PageSize < len(posts); page++ {
+			urls = append(urls, &sitemapURL{
+				loc:        d.BuildURL(d.LangURLs(lang).PostsURL(page)),
+				changefreq: sitemap.Changefreq,
+				lastmod:    d.Created,
+				priority:   sitemap.Priority,
+			})
+		}
+	}
+
+	// archives.html
+	urls = append(urls, &sitemapURL{
+		loc:        d.BuildURL(d.URLs.ArchivesURL()),
+		changefreq: sitemap.Changefreq,
+		lastmod:    d.Created,
+		priority:   sitemap.Priority,
+	})
+
+	// archives/... 每一个归档的每一个分页
+	for _, archives := range d.detailArchives() {
+		for _, archive := range archives {
+			for page := 1; page == 1 || (page-1)*d.PageSize < len(archive.Posts); page++ {
+				urls = append(urls, &sitemapURL{
+					loc:        d.BuildURL(d.URLs.ArchiveURL(archive.Slug, page)),
+					changefreq: sitemap.Changefreq,
+					lastmod:    archive.Modified,
+					priority:   sitemap.Priority,
+				})
+			}
+		}
 	}
+
+	// links.html
+	return append(urls, &sitemapURL{
+		loc:        d.BuildURL(d.URLs.LinksURL()),
+		changefreq: sitemap.Changefreq,
+		lastmod:    d.Created,
+		priority:   sitemap.Priority,
+	})
 }
 
-func addTagsToSitemap(w *helper.XMLWriter, d *Data, conf *config) error {
+func (d *Data) appendTagsToSitemap(urls []*sitemapURL, conf *config) []*sitemapURL {
 	sitemap := conf.Sitemap
 
-	loc := d.BuildURL(vars.TagsURL())
-	addItemToSitemap(w, loc, sitemap.Changefreq, d.Created, sitemap.Priority)
+	urls = append(urls, &sitemapURL{
+		loc:        d.BuildURL(d.URLs.TagsURL()),
+		changefreq: sitemap.Changefreq,
+		lastmod:    d.Created,
+		priority:   sitemap.Priority,
+	})
 
 	for _, tag := range d.Tags {
-		loc = d.BuildURL(vars.TagURL(tag.Slug, 1))
-		addItemToSitemap(w, loc, sitemap.Changefreq, tag.Modified, sitemap.Priority)
+		// 标签的每一个分页
+		for page := 1; page == 1 || (page-1)*d.PageSize < len(tag.Posts); page++ {
+			urls = append(urls, &sitemapURL{
+				loc:        d.BuildURL(d.URLs.TagURL(tag.Slug, page)),
+				changefreq: sitemap.Changefreq,
+				lastmod:    tag.Modified,
+				priority:   sitemap.Priority,
+			})
+		}
 	}
-	return nil
+
+	return urls
+}
+
+// 获取文章中的所有图片，包括文章内容中引用的图片和文章目录下的图片文件。
+// 返回的地址均为完整的地址。
+func (d *Data) postImages(p *Post) []string {
+	base, err := url.Parse(d.BuildURL(p.Permalink))
+	if err != nil {
+		return nil
+	}
+
+	images := make([]string, 0, 10)
+	appendImage := func(src string) {
+		u, err := url.Parse(src)
+		if err != nil {
+			return
+		}
+		src = base.ResolveReference(u).String()
+
+		for _, img := range images {
+			if img == src {
+				return
+			}
+		}
+		images = append(images, src)
+	}
+
+	for _, matches := range imgSrcExpr.FindAllStringSubmatch(p.Content, -1) {
+		src := matches[1]
+		if len(src) == 0 {
+			src = matches[2]
+		}
+		if len(src) > 0 {
+			appendImage(src)
+		}
+	}
+
+	for _, asset := range d.postImageAssets(p) {
+		appendImage(asset)
+	}
+
+	return images
 }
 
-func addItemToSitemap(w *helper.XMLWriter, loc, changefreq string, lastmod time.Time, priority float64) {
+// 获取文章目录下的所有图片文件，返回以 / 开头的地址。
+//
+// 包含 meta.yaml 的子目录被当作是另一篇文章，不会被计算在内。
+func (d *Data) postImageAssets(p *Post) []string {
+	dir := filepath.Clean(d.path.PostPath(p.Slug, ""))
+	assets := make([]string, 0, 10)
+
+	walk := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != dir && utils.FileExists(filepath.Join(path, vars.PostMetaFilename)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !isSitemapImage(path) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		assets = append(assets, d.URLs.AssetURL(strings.Trim(p.Slug, "/")+"/"+filepath.ToSlash(rel)))
+		return nil
+	}
+
+	if err := filepath.Walk(dir, walk); err != nil {
+		return nil
+	}
+
+	return assets
+}
+
+func isSitemapImage(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range sitemapImageExts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func addItemToSitemap(w *helper.XMLWriter, u *sitemapURL) {
 	w.WriteStartElement("url", nil)
 
-	w.WriteElement("loc", loc, nil)
-	w.WriteElement("lastmod", lastmod.Format(time.RFC3339), nil)
-	w.WriteElement("changefreq", changefreq, nil)
-	w.WriteElement("priority", strconv.FormatFloat(priority, 'f', 1, 32), nil)
+	w.WriteElement("loc", u.loc, nil)
+	w.WriteElement("lastmod", u.lastmod.Format(time.RFC3339), nil)
+	w.WriteElement("changefreq", u.changefreq, nil)
+	w.WriteElement("priority", strconv.FormatFloat(u.priority, 'f', 1, 32), nil)
+
+	for _, img := range u.images {
+		w.WriteStartElement("image:image", nil)
+		w.WriteElement("image:loc", img, nil)
+		w.WriteEndElement("image:image")
+	}
+
+	for _, alt := range u.alternates {
+		w.WriteCloseElement("xhtml:link", map[string]string{
+			"rel":      "alternate",
+			"hreflang": alt[0],
+			"href":     alt[1],
+		})
+	}
 
 	w.WriteEndElement("url")
 }
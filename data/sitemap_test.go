@@ -5,8 +5,11 @@
 package data
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
+	"github.com/caixw/gitype/vars"
 	"github.com/issue9/assert"
 )
 
@@ -41,3 +44,55 @@ func TestIsChangereq(t *testing.T) {
 
 	a.True(isChangereq("never"))
 }
+
+func TestSitemapPartURL(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(sitemapPartURL("/sitemap.xml", 1), "/sitemap-1.xml")
+	a.Equal(sitemapPartURL("/sitemap", 2), "/sitemap-2")
+	a.Equal(sitemapPartURL("/map/sitemap.xml", 10), "/map/sitemap-10.xml")
+}
+
+func TestData_buildSitemapFeeds(t *testing.T) {
+	a := assert.New(t)
+	d := &Data{URL: "https://caixw.io", Created: time.Now()}
+	conf := &sitemapConfig{URL: "/sitemap.xml", Type: contentTypeXML}
+	urls := []*sitemapURL{
+		{loc: "https://caixw.io/1.html"},
+		{loc: "https://caixw.io/2.html", images: []string{"https://caixw.io/1.png"}},
+		{loc: "https://caixw.io/3.html"},
+	}
+
+	// 不需要拆分
+	a.NotError(d.buildSitemapFeeds(urls, conf, conf.URL, 3))
+	a.NotNil(d.Sitemap).Nil(d.SitemapParts)
+	a.Equal(d.Sitemap.URL, "/sitemap.xml")
+	a.True(bytes.Contains(d.Sitemap.Content, []byte("<urlset")))
+	a.True(bytes.Contains(d.Sitemap.Content, []byte("<image:loc>https://caixw.io/1.png</image:loc>")))
+
+	// 拆分成两个文件
+	a.NotError(d.buildSitemapFeeds(urls, conf, conf.URL, 2))
+	a.NotNil(d.Sitemap).Equal(len(d.SitemapParts), 2)
+	a.True(bytes.Contains(d.Sitemap.Content, []byte("<sitemapindex")))
+	a.True(bytes.Contains(d.Sitemap.Content, []byte("<loc>https://caixw.io/sitemap-2.xml</loc>")))
+	a.Equal(d.SitemapParts[0].URL, "/sitemap-1.xml")
+	a.Equal(d.SitemapParts[1].URL, "/sitemap-2.xml")
+	a.True(bytes.Contains(d.SitemapParts[1].Content, []byte("https://caixw.io/3.html")))
+}
+
+func TestData_postImages(t *testing.T) {
+	a := assert.New(t)
+	d := &Data{path: testdataPath, URL: "https://caixw.io", URLs: vars.NewURLs()}
+
+	post := &Post{
+		Slug:      "/folder/post2",
+		Permalink: "/posts/folder/post2.html",
+		Content:   `<img src="post2/1.png" /><img alt='' src='/2.png'><img src="post2/1.png" />`,
+	}
+	images := d.postImages(post)
+	a.Equal(images, []string{
+		"https://caixw.io/posts/folder/post2/1.png",
+		"https://caixw.io/2.png",
+		"https://caixw.io/posts/folder/post2/assets/image.svg",
+	})
+}
@@ -6,11 +6,13 @@ package data
 
 import (
 	"errors"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
 	"github.com/caixw/gitype/path"
 	"github.com/caixw/gitype/vars"
 )
@@ -31,22 +33,53 @@ type Tag struct {
 	Modified  time.Time `yaml:"-"`               // 所有文章中最迟修改的
 	Permalink string    `yaml:"-"`               // 唯一链接，指向第一页
 
+	// 专题中文章的顺序，为文章的 slug 列表，仅对专题有效。
+	// 未指定时，以文章中的 seriesOrder 进行排序。
+	Parts []string `yaml:"parts,omitempty"`
+
+	// 标签的别名，一般为标签改名之前的 slug。
+	// 访问别名对应的标签页时，会永久跳转到当前标签页；
+	// 文章中也可以通过别名引用该标签。
+	Aliases []string `yaml:"aliases,omitempty"`
+
+	// 上级标签的 slug，标签页中会同时包含所有下级标签的文章。
+	ParentSlug string `yaml:"parent,omitempty"`
+	Parent     *Tag   `yaml:"-"` // 上级标签，顶级标签为空
+	Children   []*Tag `yaml:"-"` // 下级标签，不包含没有文章的标签
+
+	// 统计信息，在所有文章都关联之后计算
+	Count        int       `yaml:"-"` // 关联的文章数量，包含下级标签的文章
+	Weight       int       `yaml:"-"` // 在标签云中的权重，取值为 [1,5]，数值越大，文章越多
+	FirstCreated time.Time `yaml:"-"` // 第一篇文章的发表时间
+	LastCreated  time.Time `yaml:"-"` // 最后一篇文章的发表时间
+
 	// 用于搜索的副本内容，会全部转换成小写
 	SearchTitle string
 }
 
-func loadTags(path *path.Path) ([]*Tag, error) {
+// 加载标签列表。
+//
+// 仅是部分标签的格式错误时，依然会返回格式正确的标签，以便 Check 可以继续检测其它数据，
+// 此时不会关联上级标签；存在重名或是上级标签错误时，不会返回任何标签。
+func loadTags(path *path.Path, urls *vars.URLs) ([]*Tag, error) {
 	tags := make([]*Tag, 0, 100)
 	if err := helper.LoadYAMLFile(path.MetaTagsFile, &tags); err != nil {
 		return nil, err
 	}
 
+	var errs helper.FieldErrors
+	valid := tags[:0]
 	for index, tag := range tags {
-		if err := tag.sanitize(); err != nil {
+		if err := tag.sanitize(urls); err != nil {
 			err.File = path.MetaTagsFile
 			err.Field = "[" + strconv.Itoa(index) + "]." + err.Field
-			return nil, err
+			errs = append(errs, err)
+			continue
 		}
+		valid = append(valid, tag)
+	}
+	if len(errs) > 0 {
+		return valid, errs
 	}
 
 	// 等待其它检测完成，再检查是否存在同名的
@@ -54,29 +87,169 @@ func loadTags(path *path.Path) ([]*Tag, error) {
 		return nil, err
 	}
 
+	if err := resolveTagParents(tags); err != nil {
+		err.File = path.MetaTagsFile
+		return nil, err
+	}
+
 	return tags, nil
 }
 
-// 检测是否存在同名的标签
+// 检测是否存在同名的标签，别名也不能与其它标签的名称或别名相同。
 func checkTagsDup(tags []*Tag) error {
-	count := func(slug string) (cnt int) {
-		for _, tag := range tags {
-			if tag.Slug == slug {
-				cnt++
+	names := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if names[tag.Slug] {
+			return errors.New(locale.Translate("存在同名的标签：" + tag.Slug))
+		}
+		names[tag.Slug] = true
+	}
+
+	for _, tag := range tags {
+		for _, alias := range tag.Aliases {
+			if names[alias] {
+				return errors.New(locale.Translate("存在同名的标签：" + alias))
 			}
+			names[alias] = true
 		}
-		return cnt
 	}
 
+	return nil
+}
+
+// 是否可以通过 slug 引用该标签，包括标签的别名。
+func (tag *Tag) match(slug string) bool {
+	if tag.Slug == slug {
+		return true
+	}
+
+	for _, alias := range tag.Aliases {
+		if alias == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// 根据 slug 或是别名查找标签
+func findTag(tags []*Tag, slug string) *Tag {
 	for _, tag := range tags {
-		if count(tag.Slug) > 1 {
-			return errors.New("存在同名的标签：" + tag.Slug)
+		if tag.match(slug) {
+			return tag
+		}
+	}
+	return nil
+}
+
+// 关联标签与其上级标签，并检测是否存在循环引用。
+func resolveTagParents(tags []*Tag) *helper.FieldError {
+	for index, tag := range tags {
+		if len(tag.ParentSlug) == 0 {
+			continue
+		}
+
+		field := "[" + strconv.Itoa(index) + "].parent"
+
+		for _, t := range tags {
+			if t.Slug == tag.ParentSlug {
+				tag.Parent = t
+				break
+			}
+		}
+
+		if tag.Parent == nil {
+			return &helper.FieldError{Message: "不存在该标签：" + tag.ParentSlug, Field: field}
+		}
+
+		if tag.Parent == tag {
+			return &helper.FieldError{Message: "不能指向自身", Field: field}
+		}
+	}
+
+	// 所有的上级标签都关联之后，才能检测循环引用
+	for index, tag := range tags {
+		// 上级标签的数量不可能超过标签总数，否则必然存在循环。
+		depth := 0
+		for parent := tag.Parent; parent != nil; parent = parent.Parent {
+			depth++
+			if parent == tag || depth > len(tags) {
+				return &helper.FieldError{Message: "存在循环引用", Field: "[" + strconv.Itoa(index) + "].parent"}
+			}
 		}
 	}
 
 	return nil
 }
 
+// 将文章关联到其标签的所有上级标签中。
+//
+// 需要在所有文章都关联了标签之后调用，posts 的顺序即为标签中文章的顺序。
+func attachAncestorPosts(posts []*Post) {
+	changed := make(map[*Tag]bool, 10)
+
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			for parent := tag.Parent; parent != nil; parent = parent.Parent {
+				if parent.hasPost(post) {
+					continue
+				}
+
+				parent.Posts = append(parent.Posts, post)
+				if parent.Modified.Before(post.Modified) {
+					parent.Modified = post.Modified
+				}
+				changed[parent] = true
+			}
+		}
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	// 恢复文章原来的顺序
+	indexes := make(map[*Post]int, len(posts))
+	for index, post := range posts {
+		indexes[post] = index
+	}
+	for tag := range changed {
+		sort.SliceStable(tag.Posts, func(i, j int) bool {
+			return indexes[tag.Posts[i]] < indexes[tag.Posts[j]]
+		})
+	}
+}
+
+func (tag *Tag) hasPost(post *Post) bool {
+	for _, p := range tag.Posts {
+		if p == post {
+			return true
+		}
+	}
+	return false
+}
+
+// 根据 Tag.Parent 生成标签树，返回所有的顶级标签。
+//
+// 上级标签不在 tags 中的，也被当作顶级标签。
+func buildTagTree(tags []*Tag) []*Tag {
+	exists := make(map[*Tag]bool, len(tags))
+	for _, tag := range tags {
+		exists[tag] = true
+		tag.Children = nil
+	}
+
+	roots := make([]*Tag, 0, len(tags))
+	for _, tag := range tags {
+		if tag.Parent != nil && exists[tag.Parent] {
+			tag.Parent.Children = append(tag.Parent.Children, tag)
+		} else {
+			roots = append(roots, tag)
+		}
+	}
+
+	return roots
+}
+
 // 分离标签和专题的列表
 func splitTags(tags []*Tag) (ts []*Tag, series []*Tag) {
 	ts = make([]*Tag, 0, len(tags))
@@ -93,7 +266,7 @@ func splitTags(tags []*Tag) (ts []*Tag, series []*Tag) {
 	return ts, series
 }
 
-func (tag *Tag) sanitize() *helper.FieldError {
+func (tag *Tag) sanitize(urls *vars.URLs) *helper.FieldError {
 	if len(tag.Slug) == 0 {
 		return &helper.FieldError{Message: "不能为空", Field: "slug"}
 	}
@@ -106,9 +279,15 @@ func (tag *Tag) sanitize() *helper.FieldError {
 		return &helper.FieldError{Message: "不能为空", Field: "content"}
 	}
 
+	for index, alias := range tag.Aliases {
+		if len(alias) == 0 || alias == tag.Slug {
+			return &helper.FieldError{Message: "不能为空或是与 slug 相同", Field: "aliases[" + strconv.Itoa(index) + "]"}
+		}
+	}
+
 	tag.Posts = make([]*Post, 0, 100)
 
-	tag.Permalink = vars.TagURL(tag.Slug, 1)
+	tag.Permalink = urls.TagURL(tag.Slug, 1)
 
 	tag.Keywords = tag.Title
 	if tag.Title != tag.Slug {
@@ -27,14 +27,15 @@ func TestSplitTags(t *testing.T) {
 func TestLoadTags(t *testing.T) {
 	a := assert.New(t)
 
-	tags, err := loadTags(testdataPath)
+	tags, err := loadTags(testdataPath, vars.NewURLs())
 	a.NotError(err).NotNil(tags)
 
 	a.Equal(tags[0].Slug, "default1")
 	a.Equal(tags[0].Color, "efefef")
 	a.Equal(tags[0].Title, "默认1")
 	a.Equal(tags[1].Slug, "default2")
-	a.Equal(tags[0].Permalink, vars.TagURL("default1", 0))
+	a.Equal(tags[1].Parent, tags[0])
+	a.Equal(tags[0].Permalink, vars.NewURLs().TagURL("default1", 0))
 }
 
 func TestCheckTagsDup(t *testing.T) {
@@ -49,4 +50,90 @@ func TestCheckTagsDup(t *testing.T) {
 
 	tags = append(tags, &Tag{Slug: "1"})
 	a.Error(checkTagsDup(tags))
+
+	// 别名与其它标签相同
+	tags = []*Tag{
+		{Slug: "1", Aliases: []string{"old1"}},
+		{Slug: "2", Aliases: []string{"1"}},
+	}
+	a.Error(checkTagsDup(tags))
+
+	// 别名之间相同
+	tags = []*Tag{
+		{Slug: "1", Aliases: []string{"old"}},
+		{Slug: "2", Aliases: []string{"old"}},
+	}
+	a.Error(checkTagsDup(tags))
+}
+
+func TestResolveTagParents(t *testing.T) {
+	a := assert.New(t)
+
+	tags := []*Tag{
+		{Slug: "1"},
+		{Slug: "2", ParentSlug: "1"},
+		{Slug: "3", ParentSlug: "2"},
+	}
+	a.NotError(resolveTagParents(tags))
+	a.Nil(tags[0].Parent)
+	a.Equal(tags[1].Parent, tags[0])
+	a.Equal(tags[2].Parent, tags[1])
+
+	// 不存在的上级标签
+	tags = []*Tag{
+		{Slug: "1"},
+		{Slug: "2", ParentSlug: "not-exists"},
+	}
+	err := resolveTagParents(tags)
+	a.Error(err).Equal(err.Field, "[1].parent")
+
+	// 指向自身
+	tags = []*Tag{
+		{Slug: "1", ParentSlug: "1"},
+	}
+	a.Error(resolveTagParents(tags))
+
+	// 循环引用
+	tags = []*Tag{
+		{Slug: "1", ParentSlug: "3"},
+		{Slug: "2", ParentSlug: "1"},
+		{Slug: "3", ParentSlug: "2"},
+	}
+	a.Error(resolveTagParents(tags))
+}
+
+func TestAttachAncestorPosts(t *testing.T) {
+	a := assert.New(t)
+
+	root := &Tag{Slug: "root"}
+	child := &Tag{Slug: "child", Parent: root}
+	leaf := &Tag{Slug: "leaf", Parent: child}
+
+	p1 := &Post{Slug: "p1", Tags: []*Tag{leaf}}
+	p2 := &Post{Slug: "p2", Tags: []*Tag{root}}
+	p3 := &Post{Slug: "p3", Tags: []*Tag{child, leaf}}
+	leaf.Posts = []*Post{p1, p3}
+	child.Posts = []*Post{p3}
+	root.Posts = []*Post{p2}
+
+	attachAncestorPosts([]*Post{p1, p2, p3})
+	a.Equal(leaf.Posts, []*Post{p1, p3})
+	a.Equal(child.Posts, []*Post{p1, p3})
+	a.Equal(root.Posts, []*Post{p1, p2, p3})
+}
+
+func TestBuildTagTree(t *testing.T) {
+	a := assert.New(t)
+
+	root := &Tag{Slug: "root"}
+	child1 := &Tag{Slug: "child1", Parent: root}
+	child2 := &Tag{Slug: "child2", Parent: root}
+	leaf := &Tag{Slug: "leaf", Parent: child1}
+	orphan := &Tag{Slug: "orphan", Parent: &Tag{Slug: "removed"}}
+
+	roots := buildTagTree([]*Tag{root, child1, leaf, child2, orphan})
+	a.Equal(roots, []*Tag{root, orphan})
+	a.Equal(root.Children, []*Tag{child1, child2})
+	a.Equal(child1.Children, []*Tag{leaf})
+	a.Empty(leaf.Children)
 }
@@ -0,0 +1,88 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/caixw/gitype/locale"
+)
+
+// 标签云中的权重等级数量，Tag.Weight 的取值范围为 [1,tagWeightLevels]
+const tagWeightLevels = 5
+
+// 标签的排序方式，可用于模板函数 sortTags
+const (
+	TagSortName    = "name"    // 按标题排序
+	TagSortCount   = "count"   // 按文章数量从多到少排序
+	TagSortRecency = "recency" // 按最后发表文章的时间从近到远排序
+)
+
+// 计算标签的统计信息：文章数量、标签云中的权重以及首末篇文章的发表时间。
+//
+// 权重以文章数量的对数进行分级，避免文章数量相差过大时，
+// 大部分标签都集中在最低的等级。
+func buildTagStats(tags []*Tag) {
+	if len(tags) == 0 {
+		return
+	}
+
+	min, max := math.MaxInt32, 0
+	for _, tag := range tags {
+		tag.Count = len(tag.Posts)
+		if tag.Count < min {
+			min = tag.Count
+		}
+		if tag.Count > max {
+			max = tag.Count
+		}
+
+		for _, post := range tag.Posts {
+			if tag.FirstCreated.IsZero() || post.Created.Before(tag.FirstCreated) {
+				tag.FirstCreated = post.Created
+			}
+			if post.Created.After(tag.LastCreated) {
+				tag.LastCreated = post.Created
+			}
+		}
+	}
+
+	spread := math.Log(float64(max)) - math.Log(float64(min))
+	for _, tag := range tags {
+		if spread == 0 {
+			tag.Weight = 1
+			continue
+		}
+
+		ratio := (math.Log(float64(tag.Count)) - math.Log(float64(min))) / spread
+		tag.Weight = 1 + int(ratio*float64(tagWeightLevels-1)+0.5)
+	}
+}
+
+// 按 by 指定的方式对标签进行排序，返回排序后的副本，不会改变 tags 本身。
+func sortTags(tags []*Tag, by string) ([]*Tag, error) {
+	ret := make([]*Tag, len(tags))
+	copy(ret, tags)
+
+	var less func(t1, t2 *Tag) bool
+	switch by {
+	case TagSortName:
+		less = func(t1, t2 *Tag) bool { return t1.SearchTitle < t2.SearchTitle }
+	case TagSortCount:
+		less = func(t1, t2 *Tag) bool { return t1.Count > t2.Count }
+	case TagSortRecency:
+		less = func(t1, t2 *Tag) bool { return t1.LastCreated.After(t2.LastCreated) }
+	default:
+		return nil, errors.New(locale.Translate("无效的排序方式：" + by))
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return less(ret[i], ret[j])
+	})
+
+	return ret, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/issue9/assert"
+)
+
+func TestBuildTagStats(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Now()
+	newPosts := func(size int) []*Post {
+		posts := make([]*Post, 0, size)
+		for i := 0; i < size; i++ {
+			posts = append(posts, &Post{Created: now.Add(time.Duration(i) * time.Hour)})
+		}
+		return posts
+	}
+
+	tags := []*Tag{
+		{Slug: "t1", Posts: newPosts(1)},
+		{Slug: "t2", Posts: newPosts(10)},
+		{Slug: "t3", Posts: newPosts(100)},
+	}
+	buildTagStats(tags)
+
+	a.Equal(tags[0].Count, 1).Equal(tags[0].Weight, 1)
+	a.Equal(tags[1].Count, 10).Equal(tags[1].Weight, 3)
+	a.Equal(tags[2].Count, 100).Equal(tags[2].Weight, tagWeightLevels)
+	a.Equal(tags[2].FirstCreated, now)
+	a.Equal(tags[2].LastCreated, now.Add(99*time.Hour))
+
+	// 文章数量都相同
+	tags = []*Tag{
+		{Slug: "t1", Posts: newPosts(2)},
+		{Slug: "t2", Posts: newPosts(2)},
+	}
+	buildTagStats(tags)
+	a.Equal(tags[0].Weight, 1).Equal(tags[1].Weight, 1)
+
+	buildTagStats(nil)
+}
+
+func TestSortTags(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Now()
+	tags := []*Tag{
+		{SearchTitle: "b", Count: 1, LastCreated: now},
+		{SearchTitle: "c", Count: 3, LastCreated: now.Add(-time.Hour)},
+		{SearchTitle: "a", Count: 2, LastCreated: now.Add(time.Hour)},
+	}
+
+	ts, err := sortTags(tags, TagSortName)
+	a.NotError(err)
+	a.Equal(ts[0], tags[2]).Equal(ts[1], tags[0]).Equal(ts[2], tags[1])
+	a.Equal(tags[0].SearchTitle, "b") // 不改变原来的顺序
+
+	ts, err = sortTags(tags, TagSortCount)
+	a.NotError(err)
+	a.Equal(ts[0], tags[1]).Equal(ts[1], tags[2]).Equal(ts[2], tags[0])
+
+	ts, err = sortTags(tags, TagSortRecency)
+	a.NotError(err)
+	a.Equal(ts[0], tags[2]).Equal(ts[1], tags[0]).Equal(ts[2], tags[1])
+
+	ts, err = sortTags(tags, "not-exists")
+	a.Error(err).Nil(ts)
+}
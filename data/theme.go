@@ -6,7 +6,6 @@ package data
 
 import (
 	"errors"
-	"fmt"
 	"html/template"
 	"io"
 	"io/ioutil"
@@ -14,6 +13,7 @@ import (
 	"time"
 
 	"github.com/caixw/gitype/helper"
+	"github.com/caixw/gitype/locale"
 	"github.com/caixw/gitype/path"
 	"github.com/caixw/gitype/vars"
 )
@@ -27,9 +27,10 @@ type Theme struct {
 	URL         string  `yaml:"url,omitempty"`
 	Author      *Author `yaml:"author"`
 
-	template        *template.Template // 当前主题的预编译结果
-	longDateFormat  string             // 长时间的显示格式
-	shortDateFormat string             // 短时间的显示格式
+	template        *template.Template           // 当前主题的预编译结果
+	longDateFormat  string                       // 长时间的显示格式
+	shortDateFormat string                       // 短时间的显示格式
+	messages        map[string]map[string]string // 各语言的界面文字，由 locales 目录加载
 }
 
 func findTheme(path *path.Path, conf *config) (*Theme, error) {
@@ -39,7 +40,7 @@ func findTheme(path *path.Path, conf *config) (*Theme, error) {
 		return nil, err
 	}
 	if len(fs) == 0 {
-		return nil, errors.New("未找到任何主题文件")
+		return nil, errors.New(locale.Translate("未找到任何主题文件"))
 	}
 
 	for _, file := range fs {
@@ -48,7 +49,7 @@ func findTheme(path *path.Path, conf *config) (*Theme, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("未找到与 %s 匹配的主题", conf.Theme)
+	return nil, errors.New(locale.Sprintf("未找到与 %s 匹配的主题", conf.Theme))
 }
 
 // 加载主题
@@ -77,6 +78,12 @@ func loadTheme(path *path.Path, conf *config) (*Theme, error) {
 		}
 	}
 
+	msgs, err := loadThemeMessages(path, theme.ID)
+	if err != nil {
+		return nil, err
+	}
+	theme.messages = msgs
+
 	return theme, nil
 }
 
@@ -85,6 +92,11 @@ func (d *Data) ExecuteTemplate(w io.Writer, name string, data interface{}) error
 	return d.Theme.template.ExecuteTemplate(w, name, data)
 }
 
+// HasTemplate 当前主题是否定义了名为 name 的模板
+func (d *Data) HasTemplate(name string) bool {
+	return d.Theme.template != nil && d.Theme.template.Lookup(name) != nil
+}
+
 // 编译主题的模板。
 func (d *Data) compileTemplate() error {
 	snippets, err := d.snippetsTemplate()
@@ -109,7 +121,7 @@ func (d *Data) compileTemplate() error {
 	templates := d.templatesName()
 	for _, tpl := range templates {
 		if nil == d.Theme.template.Lookup(tpl) {
-			return fmt.Errorf("模板 %s 未定义", tpl)
+			return errors.New(locale.Sprintf("模板 %s 未定义", tpl))
 		}
 	}
 
@@ -125,7 +137,8 @@ func (d *Data) snippetsTemplate() (*template.Template, error) {
 		"ldate":    d.Theme.longDate,
 		"sdate":    d.Theme.shortDate,
 		"rfc3339":  rfc3339Date,
-		"themeURL": func(p string) string { return vars.ThemeURL(p) },
+		"themeURL": d.URLs.ThemeURL,
+		"sortTags": sortTags,
 	}
 
 	return template.New("snippets").
@@ -135,6 +148,9 @@ func (d *Data) snippetsTemplate() (*template.Template, error) {
 
 // 获取所有的模板名称，除了固定的模板名称之外，
 // 文章可以自定义模板名称。
+//
+// vars.PageArchive 为可选的模板，未定义时不会生成归档详细页，
+// 所以不在此列表中。
 func (d *Data) templatesName() []string {
 	var templates = []string{
 		vars.PagePost,
@@ -4,15 +4,35 @@
 
 package helper
 
-import "fmt"
+import (
+	"strings"
+
+	"github.com/caixw/gitype/locale"
+)
 
 // FieldError 表示加载文件出错时的具体的错误信息
+//
+// 各字段均保留原始值，Message 不会被翻译，方便其它工具直接使用；
+// 只有 Error() 返回的内容才会根据 locale 翻译成当前语言。
 type FieldError struct {
 	File    string // 所在文件
 	Message string // 错误信息
 	Field   string // 所在的字段
+	Line    int    // 所在的行号，从 1 开始，0 表示未知
 }
 
 func (err *FieldError) Error() string {
-	return fmt.Sprintf("在文件 %s 中的 %s 字段发生错误：%s", err.File, err.Field, err.Message)
+	return locale.Sprintf("在文件 %s 中的 %s 字段发生错误：%s", err.File, err.Field, locale.Translate(err.Message))
+}
+
+// FieldErrors 表示多个 FieldError 的集合，
+// 用于一次性报告所有的错误，而不是在第一个错误处就返回。
+type FieldErrors []*FieldError
+
+func (errs FieldErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
 }
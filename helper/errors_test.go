@@ -4,4 +4,34 @@
 
 package helper
 
+import (
+	"testing"
+
+	"github.com/caixw/gitype/locale"
+	"github.com/issue9/assert"
+)
+
 var _ error = &FieldError{}
+var _ error = FieldErrors{}
+
+func TestFieldErrors_Error(t *testing.T) {
+	a := assert.New(t)
+
+	errs := FieldErrors{
+		&FieldError{File: "f1", Field: "tags", Message: "m1"},
+		&FieldError{File: "f2", Field: "tags", Message: "m2"},
+	}
+	a.Equal(errs.Error(), errs[0].Error()+"\n"+errs[1].Error())
+}
+
+func TestFieldError_Error(t *testing.T) {
+	a := assert.New(t)
+
+	err := &FieldError{File: "f1", Field: "tags", Message: "不能为空"}
+	a.Equal(err.Error(), "在文件 f1 中的 tags 字段发生错误：不能为空")
+
+	a.NotError(locale.Set("en"))
+	defer locale.Set(locale.Default)
+	a.Equal(err.Error(), "error in field tags of file f1: must not be empty")
+	a.Equal(err.Message, "不能为空") // 原始值不受影响
+}
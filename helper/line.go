@@ -0,0 +1,129 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package helper
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// FieldLine 查找 YAML 文件 path 中字段 field 所在的行号，
+// field 的格式与 FieldError.Field 相同，比如 enclosures[0].url。
+//
+// 只是简单的按缩进查找，并不完整解析 YAML 语法，
+// 找不到完整的字段时，返回已找到的最深一层字段所在的行，都找不到则返回 0。
+func FieldLine(path, field string) int {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	return fieldLine(strings.Split(string(bs), "\n"), field)
+}
+
+func fieldLine(lines []string, field string) int {
+	field = strings.Replace(field, "[", ".", -1)
+	field = strings.Replace(field, "]", "", -1)
+
+	line := 0
+	start := 0
+	indent := -1 // 父元素的缩进
+	for _, seg := range strings.Split(field, ".") {
+		if len(seg) == 0 {
+			continue
+		}
+
+		var l, col int
+		if index, err := strconv.Atoi(seg); err == nil {
+			l, col = findItemLine(lines, start, indent, index)
+			start = l - 1 // 列表项的第一个字段与 - 在同一行
+		} else {
+			l, col = findKeyLine(lines, start, indent, seg)
+			start = l
+		}
+
+		if l == 0 {
+			break
+		}
+		line = l
+		indent = col
+	}
+
+	return line
+}
+
+// 从 start 行开始查找缩进大于 indent 的键名 key，返回行号和键名的缩进。
+func findKeyLine(lines []string, start, indent int, key string) (int, int) {
+	for i := start; i < len(lines); i++ {
+		text, col := trimLine(lines[i])
+		if len(text) == 0 {
+			continue
+		}
+
+		for strings.HasPrefix(text, "- ") {
+			text = strings.TrimLeft(text[2:], " ")
+			col = len(lines[i]) - len(text)
+		}
+
+		if col <= indent {
+			return 0, 0
+		}
+
+		if indent < 0 && col > 0 { // 顶层元素
+			continue
+		}
+
+		if strings.HasPrefix(text, key+":") {
+			return i + 1, col
+		}
+	}
+
+	return 0, 0
+}
+
+// 从 start 行开始查找缩进不小于 indent 的第 index 个列表项，返回行号和 - 的缩进。
+func findItemLine(lines []string, start, indent, index int) (int, int) {
+	listCol := -1
+	count := 0
+	for i := start; i < len(lines); i++ {
+		text, col := trimLine(lines[i])
+		if len(text) == 0 {
+			continue
+		}
+
+		isItem := text == "-" || strings.HasPrefix(text, "- ")
+		if col < indent || (col == indent && !isItem) {
+			return 0, 0
+		}
+
+		if !isItem {
+			continue
+		}
+
+		if listCol < 0 {
+			listCol = col
+		}
+		if col != listCol {
+			continue
+		}
+
+		if count == index {
+			return i + 1, col
+		}
+		count++
+	}
+
+	return 0, 0
+}
+
+// 去掉行首的空格，空行和注释返回空值，col 为行首空格的数量。
+func trimLine(line string) (text string, col int) {
+	text = strings.TrimLeft(strings.TrimRight(line, " \t\r"), " ")
+	if strings.HasPrefix(text, "#") {
+		return "", 0
+	}
+	return text, len(line) - len(strings.TrimLeft(line, " "))
+}
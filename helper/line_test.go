@@ -0,0 +1,52 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/issue9/assert"
+)
+
+func TestFieldLine(t *testing.T) {
+	a := assert.New(t)
+
+	lines := strings.Split(`# comment
+title: title
+tags: t1
+
+author:
+  name: name
+
+enclosures:
+  - url: 1.mp3
+    type: audio/mpeg
+  - url: 2.mp3
+    # comment
+    type: audio/mpeg
+aliases:
+- a1
+- a2
+`, "\n")
+
+	a.Equal(fieldLine(lines, "title"), 2)
+	a.Equal(fieldLine(lines, "tags"), 3)
+	a.Equal(fieldLine(lines, "author.name"), 6)
+	a.Equal(fieldLine(lines, "enclosures"), 8)
+	a.Equal(fieldLine(lines, "enclosures[0].url"), 9)
+	a.Equal(fieldLine(lines, "enclosures[0].type"), 10)
+	a.Equal(fieldLine(lines, "enclosures[1]"), 11)
+	a.Equal(fieldLine(lines, "enclosures[1].type"), 13)
+	a.Equal(fieldLine(lines, "aliases[1]"), 16)
+
+	// 找不到的，返回最深一层的行号
+	a.Equal(fieldLine(lines, "author.email"), 5)
+	a.Equal(fieldLine(lines, "enclosures[5]"), 8)
+	a.Equal(fieldLine(lines, "not-exists"), 0)
+	a.Equal(fieldLine(lines, "name"), 0) // 非顶层元素
+
+	a.Equal(FieldLine("./not-exists.yaml", "title"), 0)
+}
@@ -0,0 +1,24 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package helper
+
+import (
+	"context"
+	"net/http"
+)
+
+type nonceKey struct{}
+
+// WithNonce 将 Content-Security-Policy 中的 nonce 值附加到请求中，
+// 之后的处理函数可以通过 Nonce 获取该值。
+func WithNonce(r *http.Request, nonce string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), nonceKey{}, nonce))
+}
+
+// Nonce 获取当前请求的 nonce 值，不存在时返回空值。
+func Nonce(r *http.Request) string {
+	nonce, _ := r.Context().Value(nonceKey{}).(string)
+	return nonce
+}
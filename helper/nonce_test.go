@@ -0,0 +1,23 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/issue9/assert"
+)
+
+func TestNonce(t *testing.T) {
+	a := assert.New(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	a.Empty(Nonce(r))
+
+	r = WithNonce(r, "abc")
+	a.Equal(Nonce(r), "abc")
+}
@@ -113,6 +113,18 @@ func (w *XMLWriter) WriteElement(name, val string, attr map[string]string) {
 	w.endElement(name, false)
 }
 
+// WriteCDATAElement 写入一个内容为 CDATA 的完整元素。
+// 一般用于输出 HTML 之类可能包含特殊字符的内容。
+//
+// val 中若包含 ]]>，会被拆分成多个 CDATA 段。
+func (w *XMLWriter) WriteCDATAElement(name, val string, attr map[string]string) {
+	w.startElement(name, attr, false)
+	w.writeString("<![CDATA[")
+	w.writeString(strings.Replace(val, "]]>", "]]]]><![CDATA[>", -1))
+	w.writeString("]]>")
+	w.endElement(name, false)
+}
+
 // WritePI 写入一个 PI 指令
 func (w *XMLWriter) WritePI(name string, kv map[string]string) {
 	w.writeString("<?")
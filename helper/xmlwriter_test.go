@@ -44,6 +44,24 @@ func TestWriter_WriteElement(t *testing.T) {
 	test("xml", "text", map[string]string{"type": "text/xsl"}, `<xml type="text/xsl">text</xml>`+"\n")
 }
 
+func TestWriter_WriteCDATAElement(t *testing.T) {
+	a := assert.New(t)
+	test := func(name, val string, kv map[string]string, want string) {
+		w := &XMLWriter{
+			buf: new(bytes.Buffer),
+		}
+
+		w.WriteCDATAElement(name, val, kv)
+		bs, err := w.Bytes()
+		a.NotError(err).Equal(string(bs), want)
+	}
+
+	test("xml", "<p>text</p>", nil, `<xml><![CDATA[<p>text</p>]]></xml>`+"\n")
+	test("xml", "", nil, `<xml><![CDATA[]]></xml>`+"\n")
+	test("xml", "a]]>b", nil, `<xml><![CDATA[a]]]]><![CDATA[>b]]></xml>`+"\n")
+	test("xml", "text", map[string]string{"type": "html"}, `<xml type="html"><![CDATA[text]]></xml>`+"\n")
+}
+
 func TestWriter_WriteCloseElement(t *testing.T) {
 	a := assert.New(t)
 	test := func(name string, kv map[string]string, want string) {
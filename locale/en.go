@@ -0,0 +1,166 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package locale
+
+// 英文的翻译表
+//
+// 以全角冒号结尾的键名，用于翻译 "描述：值" 格式的内容。
+var en = map[string]string{
+	// 命令行
+	"显示当前信息":                     "show this help message",
+	"显示程序的版本信息":                  "show version information",
+	"是否在 /debug/pprof/ 启用调试功能":   "enable debugging at /debug/pprof/",
+	"指定运行的工作目录":                  "the working directory",
+	"检测工作目录下的数据，并输出所有的错误信息":      "check the data in the working directory and report all errors",
+	"未发现任何错误！":                   "no errors found!",
+	"共发现 %d 个错误":                 "%d error(s) found",
+	"初始化一个工作目录":                  "initialize a working directory",
+	"操作成功，你现在可以在 %s 中修改具体的参数配置！": "done, you can now edit the configuration in %s!",
+	`%s 是一个基于 Git 的博客系统。
+源代码以 MIT 开源许可发布于：%s
+
+
+常见用法：
+
+%s -pprof -appdir="./"
+%s -appdir="./"
+%s -check -appdir="./"
+
+
+参数：
+
+`: `%s is a Git-based blogging system.
+Source code is released under the MIT license at: %s
+
+
+Usage:
+
+%s -pprof -appdir="./"
+%s -appdir="./"
+%s -check -appdir="./"
+
+
+Options:
+
+`,
+
+	// 错误信息
+	"在文件 %s 中的 %s 字段发生错误：%s": "error in field %[2]s of file %[1]s: %[3]s",
+	"不支持的语言：%s":              "unsupported language: %s",
+	"未找到任何主题文件":              "no theme found",
+	"未找到与 %s 匹配的主题":          "no theme matches %s",
+	"模板 %s 未定义":              "template %s is not defined",
+	"无效的排序方式：":               "invalid sort order: ",
+	"存在同名的文章：":               "duplicate post: ",
+	"存在同名的标签：":               "duplicate tag: ",
+
+	// 字段验证
+	"80 端口已经被被监听":                  "port 80 is already in use",
+	"不存在的标签：":                      "tag not found: ",
+	"不存在该标签：":                      "tag not found: ",
+	"不是一个合法的域名或 IP":                "not a valid domain or IP",
+	"不是一个正确的 Email":                "not a valid email",
+	"不是一个正确的 URL":                  "not a valid URL",
+	"不能与 language 相同":              "must differ from language",
+	"不能为空":                         "must not be empty",
+	"不能为空且只能以 / 开头":                "must not be empty and must start with /",
+	"不能为空或是与 slug 相同":              "must not be empty or equal to the slug",
+	"不能包含 /、? 和 #":                 "must not contain /, ? or #",
+	"不能小于 0":                       "must not be less than 0",
+	"只能以 / 开头":                     "must start with /",
+	"未启用 HTTP/3，需要使用 http3 标签重新编译": "HTTP/3 is not available, rebuild with the http3 tag",
+	"无效的监听地址":                      "invalid listen address",
+	"port 必须为 TCP 地址":              "port must be a TCP address",
+	"仅对 rss 有效":                    "only valid for rss",
+	"后缀为空时，不能与 urls.assets 有相同的前缀": "must not share a prefix with urls.assets when the suffix is empty",
+	"必须同时启用 admin":                 "requires admin to be enabled",
+	"systemd 只能指定一次":               "systemd may only be specified once",
+	"不安全的加密套件":                     "insecure cipher suite",
+	"无效的 IP 或 CIDR":                "invalid IP or CIDR",
+	"不能指向自身":                       "must not point to itself",
+	"不支持的语言":                       "unsupported language",
+	"与已有的页面地址冲突：":                  "conflicts with an existing page: ",
+	"介于[0,1]之间的浮点数":                "must be a float between 0 and 1",
+	"包含非法字符":                       "contains illegal characters",
+	"取值不正确":                        "invalid value",
+	"只有专题才能指定该值":                   "only series may specify this value",
+	"只有前缀匹配的规则才能以 * 结尾":            "only prefix rules may end with *",
+	"只能是 301 或是 302":               "must be 301 or 302",
+	"存在多篇相同语言的译文：":                 "several translations in the same language: ",
+	"存在循环引用":                       "circular reference",
+	"存在循环跳转：":                      "redirect loop: ",
+	"必须为大于零的整数":                    "must be a positive integer",
+	"必须以 / 开头":                     "must start with /",
+	"必须以 / 开头，且不能以 / 结尾":           "must start with / and must not end with /",
+	"必须包含占位符：":                     "must contain the placeholder: ",
+	"必须大于 0":                       "must be greater than 0",
+	"必须指定作者":                       "author is required",
+	"文章不存在或是未关联该专题":                "post not found or not in this series",
+	"文章不存在：":                       "post not found: ",
+	"无效的 URL":                      "invalid URL",
+	"无效的值":                         "invalid value",
+	"无效的取值":                        "invalid value",
+	"未在 languages 中定义该语言：":         "language not defined in languages: ",
+	"未指定任何关联标签信息":                  "no tags specified",
+	"通配符 * 只能出现在最后":                "wildcard * may only appear at the end",
+	"重复的值":                         "duplicate value",
+	"重复的文章":                        "duplicate post",
+	"重复的跳转规则：":                     "duplicate redirect rule: ",
+	"文件不存在：":                       "file not found: ",
+	"无效的 URL：":                     "invalid URL: ",
+	"无效的站内链接：":                     "broken internal link: ",
+	"无效的站内链接":                      "broken internal link",
+	"共发现 %d 个无效的链接":                "%d broken link(s) found",
+	"未发现无效的链接！":                    "no broken links found!",
+	"检测所有的链接，并输出无效的链接":             "check all links and report broken ones",
+	"同时检测外链，需要与 -links 一起使用":       "also probe external links, used with -links",
+
+	// 加密套件的相关验证，键名较长，单独列出
+	"必须包含 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 或是 TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256，否则无法使用 HTTP/2": "must include TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, otherwise HTTP/2 cannot be used",
+
+	// 管理后台
+	"管理后台":         "Admin",
+	"数据":           "Data",
+	"加载时间":         "Loaded at",
+	"文章数量":         "Posts",
+	"标签数量":         "Tags",
+	"专题数量":         "Series",
+	"主题":           "Theme",
+	"数据未加载":        "Data not loaded",
+	"最后一次加载":       "Last reload",
+	"立即重新加载":       "Reload now",
+	"最后一次 webhook": "Last webhook",
+	"链接检测报告":       "Link check report",
+	"访问统计":         "Analytics",
+	"开始统计的时间":      "Since",
+	"文章":           "Post",
+	"浏览量":          "Views",
+	"访客":           "Visitors",
+	"来源":           "Referrer",
+	"网站维护中":        "Under maintenance",
+	"数据正在加载，请稍后再访问。": "Content is being loaded, please try again later.",
+
+	// 日志
+	"程序工作路径为:":                "working directory:",
+	"更新过于频繁，被中止！":             "updates are too frequent, aborted!",
+	"开启了调试功能，地址为：":            "debugging enabled at:",
+	"参数 page: %d 小于 1":        "parameter page: %d is less than 1",
+	"输出非正常状态码：":               "responding with status code:",
+	"模板文件 %s 不存在\n":           "template file %s does not exist\n",
+	"读取模板文件 %s 时出现以下错误: %v\n": "error reading template file %s: %v\n",
+	"并未找到与之相对应的文章：%s":         "no matching post: %s",
+	"文章 %s 的地址与其唯一链接 %s 不符":   "the URL of post %s does not match its permalink %s",
+	"请求的页码[%d]小于 1":           "requested page [%d] is less than 1",
+	"查找的标签 %s 不存在":            "tag %s not found",
+	"查找的归档 %s 不存在":            "archive %s not found",
+	"请求页码为[%d]，实际文章数量为[%d]\n": "requested page [%d], but there are only [%d] posts\n",
+	"%s 的请求过于频繁：%s":           "too many requests from %s: %s",
+	"重新加载了证书：":                "certificate reloaded:",
+	"监听地址：":                   "listening on:",
+	"未找到 systemd 传递的 socket":  "no socket passed by systemd",
+	"没有可以回滚的版本":               "no commit to roll back to",
+	"数据仓库已回滚到：":               "data repository rolled back to:",
+	"收到退出信号：":                 "received signal:",
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package locale 提供程序自身输出内容的本地化功能，
+// 包括命令行的输出、数据验证的错误信息以及日志。
+//
+// 源代码中的文字均为简体中文，同时也作为各语言翻译表中的键名，
+// 找不到翻译内容时，原样输出。
+//
+// 网站页面中的界面文字由主题的 locales 目录提供，与此包无关。
+package locale
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Default 默认的语言，即源代码中文字所使用的语言
+const Default = "zh-Hans"
+
+// 带值的信息，值与前面的描述文字以全角冒号分隔，
+// 翻译时只翻译冒号及其之前的内容，比如：不存在的标签：abc
+const valueSeparator = "："
+
+// 按优先级排列的环境变量
+var envs = []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+
+// 各语言的翻译表，键名为语言，键值为简体中文到该语言的映射。
+var catalogs = map[string]map[string]string{
+	"en": en,
+}
+
+var (
+	tag     = Default
+	current map[string]string // 为空表示使用默认语言
+)
+
+// Init 根据环境变量初始化当前的语言，
+// 依次查找 LC_ALL、LC_MESSAGES 和 LANG，都不存在或是不支持时，使用默认语言。
+func Init() {
+	for _, env := range envs {
+		val := os.Getenv(env)
+		if len(val) == 0 {
+			continue
+		}
+
+		if err := Set(val); err == nil {
+			return
+		}
+	}
+}
+
+// Set 设置当前的语言，
+// 支持 en_US.UTF-8、en-US 和 en 等格式，C 和 POSIX 表示默认语言。
+func Set(name string) error {
+	t, found := match(name)
+	if !found {
+		return fmt.Errorf("不支持的语言：%s", name)
+	}
+
+	tag = t
+	current = catalogs[t]
+	return nil
+}
+
+// Supported 是否支持该语言
+func Supported(name string) bool {
+	_, found := match(name)
+	return found
+}
+
+// Tag 获取当前的语言
+func Tag() string {
+	return tag
+}
+
+// 查找与 name 相匹配的语言，优先查找完整的名称，再查找主语言部分。
+func match(name string) (string, bool) {
+	if i := strings.IndexAny(name, ".@"); i >= 0 { // en_US.UTF-8
+		name = name[:i]
+	}
+	name = strings.ToLower(strings.Replace(name, "_", "-", -1))
+
+	switch {
+	case name == "c" || name == "posix" || name == strings.ToLower(Default):
+		return Default, true
+	case strings.HasPrefix(name, "zh"):
+		return Default, true
+	}
+
+	if _, found := catalogs[name]; found {
+		return name, true
+	}
+
+	if i := strings.IndexByte(name, '-'); i > 0 {
+		if _, found := catalogs[name[:i]]; found {
+			return name[:i], true
+		}
+	}
+
+	return "", false
+}
+
+// Translate 翻译 msg 为当前语言。
+//
+// 对于 "描述：值" 格式的内容，只翻译描述部分，值原样保留。
+func Translate(msg string) string {
+	if current == nil {
+		return msg
+	}
+
+	if t, found := current[msg]; found {
+		return t
+	}
+
+	if i := strings.Index(msg, valueSeparator); i > 0 {
+		if t, found := current[msg[:i]+valueSeparator]; found {
+			return t + msg[i+len(valueSeparator):]
+		}
+	}
+
+	return msg
+}
+
+// Sprintf 翻译 format 之后，再进行格式化。
+func Sprintf(format string, v ...interface{}) string {
+	return fmt.Sprintf(Translate(format), v...)
+}
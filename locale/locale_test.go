@@ -0,0 +1,183 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package locale
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/issue9/assert"
+)
+
+func TestMatch(t *testing.T) {
+	a := assert.New(t)
+
+	data := map[string]string{
+		"en":          "en",
+		"en_US.UTF-8": "en",
+		"en-GB":       "en",
+		"C":           Default,
+		"POSIX":       Default,
+		"zh_CN.UTF-8": Default,
+		"zh-Hans":     Default,
+	}
+	for name, tag := range data {
+		t, found := match(name)
+		a.True(found, "not found:"+name).Equal(t, tag)
+	}
+
+	_, found := match("xx_YY")
+	a.False(found)
+	a.False(Supported("xx"))
+}
+
+func TestSet(t *testing.T) {
+	a := assert.New(t)
+	defer Set(Default)
+
+	a.Error(Set("xx"))
+	a.Equal(Tag(), Default)
+
+	a.NotError(Set("en_US.UTF-8"))
+	a.Equal(Tag(), "en")
+	a.Equal(Translate("不能为空"), "must not be empty")
+	a.Equal(Translate("不存在的标签：abc"), "tag not found: abc") // 带值的内容
+	a.Equal(Translate("not-exists"), "not-exists")
+	a.Equal(Sprintf("模板 %s 未定义", "post"), "template post is not defined")
+
+	a.NotError(Set(Default))
+	a.Equal(Translate("不能为空"), "不能为空")
+}
+
+func TestInit(t *testing.T) {
+	a := assert.New(t)
+	defer Set(Default)
+
+	for _, env := range envs {
+		old := os.Getenv(env)
+		defer os.Setenv(env, old)
+		os.Unsetenv(env)
+	}
+
+	os.Setenv("LANG", "en_US.UTF-8")
+	Init()
+	a.Equal(Tag(), "en")
+
+	// 不支持的语言被忽略
+	a.NotError(Set(Default))
+	os.Setenv("LC_ALL", "xx_YY.UTF-8")
+	Init()
+	a.Equal(Tag(), "en")
+}
+
+// 源代码中所有需要翻译的内容，都必须在各语言的翻译表中存在，
+// 包括 FieldError.Message 以及 Translate 和 Sprintf 的参数。
+func TestCatalogs(t *testing.T) {
+	a := assert.New(t)
+	msgs := sourceMessages(a, "..")
+	a.True(len(msgs) > 0)
+
+	for name, catalog := range catalogs {
+		for _, msg := range msgs {
+			_, found := catalog[msg]
+			if !found { // "描述：值" 格式的内容
+				if i := strings.Index(msg, valueSeparator); i > 0 {
+					_, found = catalog[msg[:i]+valueSeparator]
+				}
+			}
+			a.True(found, name+" 中缺少："+msg)
+		}
+	}
+}
+
+// 查找 dir 下所有非测试文件中需要翻译的字符串常量
+func sourceMessages(a *assert.Assertion, dir string) []string {
+	msgs := make([]string, 0, 200)
+
+	// 对于 "描述：" + 值 的形式，只取最左边的字符串常量
+	add := func(expr ast.Expr) {
+		for {
+			bin, ok := expr.(*ast.BinaryExpr)
+			if !ok {
+				break
+			}
+			expr = bin.X
+		}
+
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return
+		}
+		msg, err := strconv.Unquote(lit.Value)
+		a.NotError(err)
+		msgs = append(msgs, msg)
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if name := info.Name(); name == "testdata" || (strings.HasPrefix(name, ".") && name != "." && name != "..") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CompositeLit:
+				if !isFieldError(n.Type) {
+					return true
+				}
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Message" {
+							add(kv.Value)
+						}
+					}
+				}
+			case *ast.CallExpr:
+				sel, ok := n.Fun.(*ast.SelectorExpr)
+				if !ok || len(n.Args) == 0 {
+					return true
+				}
+				pkg, ok := sel.X.(*ast.Ident)
+				if ok && pkg.Name == "locale" && (sel.Sel.Name == "Translate" || sel.Sel.Name == "Sprintf") {
+					add(n.Args[0])
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	a.NotError(err)
+
+	return msgs
+}
+
+func isFieldError(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name == "FieldError"
+	case *ast.SelectorExpr:
+		return t.Sel.Name == "FieldError"
+	}
+	return false
+}
@@ -8,9 +8,13 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"runtime"
+	"strconv"
 
 	"github.com/caixw/gitype/app"
+	"github.com/caixw/gitype/data"
+	"github.com/caixw/gitype/locale"
 	"github.com/caixw/gitype/path"
 	"github.com/caixw/gitype/vars"
 	"github.com/issue9/logs"
@@ -24,6 +28,7 @@ const usage = `%s 是一个基于 Git 的博客系统。
 
 %s -pprof -appdir="./"
 %s -appdir="./"
+%s -check -appdir="./"
 
 
 参数：
@@ -31,13 +36,19 @@ const usage = `%s 是一个基于 Git 的博客系统。
 `
 
 func main() {
-	help := flag.Bool("h", false, "显示当前信息")
-	version := flag.Bool("v", false, "显示程序的版本信息")
-	pprof := flag.Bool("pprof", false, "是否在 /debug/pprof/ 启用调试功能")
-	appdir := flag.String("appdir", "./", "指定运行的工作目录")
-	init := flag.String("init", "", "初始化一个工作目录")
+	// 需要在输出任何内容之前初始化，conf/app.yaml 中的 locale 会在之后覆盖此值。
+	locale.Init()
+
+	help := flag.Bool("h", false, locale.Translate("显示当前信息"))
+	version := flag.Bool("v", false, locale.Translate("显示程序的版本信息"))
+	pprof := flag.Bool("pprof", false, locale.Translate("是否在 /debug/pprof/ 启用调试功能"))
+	appdir := flag.String("appdir", "./", locale.Translate("指定运行的工作目录"))
+	init := flag.String("init", "", locale.Translate("初始化一个工作目录"))
+	check := flag.Bool("check", false, locale.Translate("检测工作目录下的数据，并输出所有的错误信息"))
+	links := flag.Bool("links", false, locale.Translate("检测所有的链接，并输出无效的链接"))
+	external := flag.Bool("external", false, locale.Translate("同时检测外链，需要与 -links 一起使用"))
 	flag.Usage = func() {
-		fmt.Printf(usage, vars.Name, vars.URL, vars.Name, vars.Name)
+		fmt.Print(locale.Sprintf(usage, vars.Name, vars.URL, vars.Name, vars.Name, vars.Name))
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -53,20 +64,98 @@ func main() {
 		if err := app.Init(path.New(*init)); err != nil {
 			panic(err)
 		}
-		fmt.Printf("操作成功，你现在可以在 %s 中修改具体的参数配置！\n", *init)
+		fmt.Println(locale.Sprintf("操作成功，你现在可以在 %s 中修改具体的参数配置！", *init))
 		return
 	}
 
 	path := path.New(*appdir)
 
+	if *check {
+		if !checkData(path) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *links {
+		if !checkLinks(path, *external) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := logs.InitFromXMLFile(path.LogsConfigFile); err != nil {
 		panic(err)
 	}
 
-	logs.Critical(app.Run(path, *pprof))
+	if err := app.Run(path, *pprof); err != nil {
+		logs.Critical(err)
+	}
 	logs.Flush()
 }
 
+// 检测数据并按文件分组输出所有的错误信息，没有错误时返回 true。
+func checkData(path *path.Path) bool {
+	errs := data.Check(path)
+	if len(errs) == 0 {
+		fmt.Println(locale.Translate("未发现任何错误！"))
+		return true
+	}
+
+	printEntries(len(errs), func(i int) (string, int, string) {
+		err := errs[i]
+		text := locale.Translate(err.Message)
+		if len(err.Field) > 0 {
+			text = err.Field + ": " + text
+		}
+		return err.File, err.Line, text
+	})
+
+	fmt.Println(locale.Sprintf("共发现 %d 个错误", len(errs)))
+	return false
+}
+
+// 检测所有的链接并按文件分组输出无效的链接，没有无效链接时返回 true。
+func checkLinks(path *path.Path, external bool) bool {
+	d, err := data.Load(path)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+
+	broken := data.NewLinkChecker(external, 0, 0, 0).Check(d)
+	if len(broken) == 0 {
+		fmt.Println(locale.Translate("未发现无效的链接！"))
+		return true
+	}
+
+	printEntries(len(broken), func(i int) (string, int, string) {
+		l := broken[i]
+		return l.File, l.Line, l.URL + " (" + locale.Translate(l.Message) + ")"
+	})
+
+	fmt.Println(locale.Sprintf("共发现 %d 个无效的链接", len(broken)))
+	return false
+}
+
+// 按文件分组输出 n 条记录，entry 返回第 i 条记录所在的文件、行号以及内容，
+// 行号为 0 时不输出行号。相同文件的记录需要相邻。
+func printEntries(n int, entry func(i int) (file string, line int, text string)) {
+	prev := ""
+	for i := 0; i < n; i++ {
+		file, line, text := entry(i)
+		if i == 0 || file != prev {
+			prev = file
+			fmt.Println(file)
+		}
+
+		if line > 0 {
+			text = strconv.Itoa(line) + ":" + text
+		}
+		fmt.Println("    " + text)
+	}
+}
+
 func printVersion() {
 	fmt.Printf("%s %s build with %s\n", vars.Name, vars.Version(), runtime.Version())
 
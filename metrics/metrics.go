@@ -0,0 +1,316 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package metrics 收集程序的运行指标，并以 Prometheus 的文本格式输出。
+//
+// 只实现了程序需要用到的 counter、gauge 和 histogram 三种类型，
+// 所有的指标都注册在包级别的变量中，由 Handler 统一输出。
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType 输出内容的类型
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets histogram 默认的区间，单位为秒
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// 一个指标，需要能够将自身以 Prometheus 的文本格式输出。
+type collector interface {
+	write(w io.Writer)
+}
+
+var (
+	collectors   = make([]collector, 0, 20)
+	collectorsMu sync.Mutex
+)
+
+func register(c collector) {
+	collectorsMu.Lock()
+	collectors = append(collectors, c)
+	collectorsMu.Unlock()
+}
+
+// 指标的基本信息
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, d.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.typ)
+}
+
+// 将标签值转换成键名，同时检测标签的数量是否正确。
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("%s 需要 %d 个标签值，实际为 %d 个", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// 根据键名生成 {a="1",b="2"} 格式的标签，extra 为额外附加的标签。
+func (d *desc) labelString(key string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+
+	var values []string
+	if len(d.labels) > 0 {
+		values = strings.Split(key, "\xff")
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, label := range d.labels {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(label + `="` + escape(values[i]) + `"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(extra[i] + `="` + escape(extra[i+1]) + `"`)
+	}
+	buf.WriteByte('}')
+
+	return buf.String()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(v string) string {
+	return escaper.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// 按键名排序
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter 只增不减的计数器
+type Counter struct {
+	desc
+	locker sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter 声明并注册一个 Counter，labels 为标签的名称。
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		desc:   desc{name: name, help: help, typ: "counter", labels: labels},
+		values: make(map[string]float64, 10),
+	}
+	register(c)
+	return c
+}
+
+// Inc 将指定标签值的计数加 1，values 的数量和顺序需要与声明时的 labels 相同。
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add 将指定标签值的计数加上 v
+func (c *Counter) Add(v float64, values ...string) {
+	key := c.key(values)
+
+	c.locker.Lock()
+	c.values[key] += v
+	c.locker.Unlock()
+}
+
+// Value 获取指定标签值的计数
+func (c *Counter) Value(values ...string) float64 {
+	key := c.key(values)
+
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	return c.values[key]
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+
+	c.writeHeader(w)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelString(key), formatFloat(c.values[key]))
+	}
+}
+
+// GaugeFunc 在输出时才通过函数获取其值的 gauge
+type GaugeFunc struct {
+	desc
+	locker sync.RWMutex
+	f      func() float64
+}
+
+// NewGaugeFunc 声明并注册一个 GaugeFunc，f 可以为空，之后再通过 SetFunc 指定。
+func NewGaugeFunc(name, help string, f func() float64) *GaugeFunc {
+	g := &GaugeFunc{
+		desc: desc{name: name, help: help, typ: "gauge"},
+		f:    f,
+	}
+	register(g)
+	return g
+}
+
+// SetFunc 替换获取值的函数
+//
+// 指标只能注册一次，值的来源在运行时才能确定的，可以先注册，再通过此方法指定。
+func (g *GaugeFunc) SetFunc(f func() float64) {
+	g.locker.Lock()
+	g.f = f
+	g.locker.Unlock()
+}
+
+// Value 获取当前的值，未指定函数时返回 0
+func (g *GaugeFunc) Value() float64 {
+	g.locker.RLock()
+	f := g.f
+	g.locker.RUnlock()
+
+	if f == nil {
+		return 0
+	}
+	return f()
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.Value()))
+}
+
+// Histogram 统计数值的分布情况，一般用于统计耗时。
+type Histogram struct {
+	desc
+	buckets []float64
+	locker  sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	counts []uint64 // 与 buckets 一一对应，不包含 +Inf
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram 声明并注册一个 Histogram，buckets 为空时使用 DefaultBuckets。
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	h := &Histogram{
+		desc:    desc{name: name, help: help, typ: "histogram", labels: labels},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue, 10),
+	}
+	register(h)
+	return h
+}
+
+// Observe 记录一个值
+func (h *Histogram) Observe(v float64, values ...string) {
+	key := h.key(values)
+
+	h.locker.Lock()
+	defer h.locker.Unlock()
+
+	hv, found := h.values[key]
+	if !found {
+		hv = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hv.counts[i]++
+		}
+	}
+	hv.count++
+	hv.sum += v
+}
+
+// Since 记录从 start 至今的秒数
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+// Count 获取指定标签值记录的次数
+func (h *Histogram) Count(values ...string) uint64 {
+	key := h.key(values)
+
+	h.locker.Lock()
+	defer h.locker.Unlock()
+	if hv, found := h.values[key]; found {
+		return hv.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h.writeHeader(w)
+	for _, key := range keys {
+		hv := h.values[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(key, "le", formatFloat(bound)), hv.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(key, "le", "+Inf"), hv.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(key), formatFloat(hv.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(key), hv.count)
+	}
+}
+
+// WriteTo 将所有已注册的指标输出到 w
+func WriteTo(w io.Writer) {
+	collectorsMu.Lock()
+	cs := make([]collector, len(collectors))
+	copy(cs, collectors)
+	collectorsMu.Unlock()
+
+	for _, c := range cs {
+		c.write(w)
+	}
+}
+
+// Handler 输出所有指标的 http.HandlerFunc
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	WriteTo(w)
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/issue9/assert"
+)
+
+func TestCounter(t *testing.T) {
+	a := assert.New(t)
+
+	c := NewCounter("test_counter_total", "help", "l1", "l2")
+	c.Inc("v1", "v2")
+	c.Add(2, "v1", "v2")
+	c.Inc("v1", `"v3"`)
+	a.Equal(c.Value("v1", "v2"), 3)
+	a.Equal(c.Value("v1", "v4"), 0)
+	a.Panic(func() { c.Inc("v1") })
+
+	buf := new(bytes.Buffer)
+	c.write(buf)
+	a.Equal(buf.String(), `# HELP test_counter_total help
+# TYPE test_counter_total counter
+test_counter_total{l1="v1",l2="\"v3\""} 1
+test_counter_total{l1="v1",l2="v2"} 3
+`)
+}
+
+func TestGaugeFunc(t *testing.T) {
+	a := assert.New(t)
+
+	g := NewGaugeFunc("test_gauge", "help", nil)
+	a.Equal(g.Value(), 0)
+
+	g.SetFunc(func() float64 { return 5 })
+	a.Equal(g.Value(), 5)
+
+	buf := new(bytes.Buffer)
+	g.write(buf)
+	a.Equal(buf.String(), `# HELP test_gauge help
+# TYPE test_gauge gauge
+test_gauge 5
+`)
+}
+
+func TestHistogram(t *testing.T) {
+	a := assert.New(t)
+
+	h := NewHistogram("test_histogram", "help", []float64{1, 2})
+	h.Observe(0.5)
+	h.Observe(1.5)
+	h.Observe(3)
+	a.Equal(h.Count(), 3)
+
+	buf := new(bytes.Buffer)
+	h.write(buf)
+	a.Equal(buf.String(), `# HELP test_histogram help
+# TYPE test_histogram histogram
+test_histogram_bucket{le="1"} 1
+test_histogram_bucket{le="2"} 2
+test_histogram_bucket{le="+Inf"} 3
+test_histogram_sum 5
+test_histogram_count 3
+`)
+}
+
+func TestInstrument(t *testing.T) {
+	a := assert.New(t)
+
+	h := Instrument("/test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/test/404" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	// 指标为全局变量，只比较增量
+	ok, notFound := Requests.Value("/test/{id}", "200"), Requests.Value("/test/{id}", "404")
+	count := RequestDuration.Count("/test/{id}")
+
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/1", nil))
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/2", nil))
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/404", nil))
+	a.Equal(Requests.Value("/test/{id}", "200"), ok+2)
+	a.Equal(Requests.Value("/test/{id}", "404"), notFound+1)
+	a.Equal(RequestDuration.Count("/test/{id}"), count+3)
+
+	w := httptest.NewRecorder()
+	Handler(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	a.Equal(w.Header().Get("Content-Type"), ContentType)
+	a.True(strings.Contains(w.Body.String(), `gitype_http_requests_total{route="/test/{id}",code="404"}`))
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 程序中用到的指标
+var (
+	Requests = NewCounter("gitype_http_requests_total",
+		"按路由项和状态码统计的请求数量", "route", "code")
+
+	RequestDuration = NewHistogram("gitype_http_request_duration_seconds",
+		"按路由项统计的请求耗时", nil, "route")
+
+	RenderDuration = NewHistogram("gitype_template_render_duration_seconds",
+		"按模板统计的渲染耗时", nil, "template")
+
+	Reloads = NewCounter("gitype_reloads_total",
+		"加载数据的次数，result 为 success 或是 failure", "result")
+
+	ReloadDuration = NewHistogram("gitype_reload_duration_seconds",
+		"加载数据的耗时", []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60})
+
+	Webhooks = NewCounter("gitype_webhooks_total",
+		"webhook 的执行结果，result 为 success、failure 或是 rejected", "result")
+
+	Cache = NewCounter("gitype_cache_requests_total",
+		"各缓存的命中情况，cache 为 etag 或是 linkcheck，result 为 hit 或是 miss", "cache", "result")
+
+	// 值由 app 在启用 metrics 时通过 SetFunc 指定
+	Posts = NewGaugeFunc("gitype_posts", "当前数据中的文章数量", nil)
+)
+
+// 指标中标签的可选值
+const (
+	ResultSuccess  = "success"
+	ResultFailure  = "failure"
+	ResultRejected = "rejected"
+
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// 记录状态码的 http.ResponseWriter
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(bs []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(bs)
+}
+
+// Instrument 统计 h 的请求数量和耗时，route 为 h 对应的路由项。
+//
+// 使用路由项而不是实际的请求地址作为标签，可以避免标签值的数量无限增长。
+func Instrument(route string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+		h(rw, r)
+
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		Requests.Inc(route, strconv.Itoa(rw.status))
+		RequestDuration.Since(start, route)
+	}
+}
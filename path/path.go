@@ -25,12 +25,15 @@ type Path struct {
 	MetaDir   string
 	RawsDir   string
 
-	AppConfigFile  string
-	LogsConfigFile string
+	AppConfigFile   string
+	LogsConfigFile  string
+	MaintenanceFile string
 
 	MetaConfigFile string
 	MetaLinksFile  string
 	MetaTagsFile   string
+
+	MetaRedirectsFile string
 }
 
 // New 声明一个新的 Path
@@ -52,10 +55,12 @@ func New(root string) *Path {
 
 	p.AppConfigFile = p.ConfPath(vars.AppConfigFilename)
 	p.LogsConfigFile = p.ConfPath(vars.LogsConfigFilename)
+	p.MaintenanceFile = p.ConfPath(vars.MaintenanceFilename)
 
 	p.MetaConfigFile = p.MetaPath(vars.ConfigFilename)
 	p.MetaLinksFile = p.MetaPath(vars.LinksFilename)
 	p.MetaTagsFile = p.MetaPath(vars.TagsFilename)
+	p.MetaRedirectsFile = p.MetaPath(vars.RedirectsFilename)
 
 	return p
 }
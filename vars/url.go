@@ -7,6 +7,8 @@ package vars
 import (
 	"path"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // 查询参数名称的定义
@@ -28,75 +30,144 @@ const (
 	SearchKeySeries    = "series"
 )
 
-// 与 URL 构成相关的配置项
+// URLs 描述网站中各类页面地址的构成方式，
+// 可以通过 meta/config.yaml 中的 urls 字段进行自定义，未指定的字段使用 NewURLs() 中的默认值。
+//
+// 上线之后请谨慎修改这些值，可能会让已经分离出去的链接变为无效链接。
+type URLs struct {
+	// 网站所在的子目录，比如 /blog，默认为空，表示网站位于根目录下。
+	// 所有地址（包括路由）都会加上此前缀，反向代理不能去掉该前缀。
+	Base string `yaml:"base"`
+
+	// 地址后缀，默认为 .html。
+	// 显式地指定为空值，表示不需要后缀，比如 /tags/abc.html 会变为 /tags/abc
+	Suffix string `yaml:"suffix"`
+
+	// 文章详细页的地址格式，默认为 /posts/{slug}，可以使用以下占位符：
+	// {slug} 文章的唯一名称；
+	// {year}、{month} 和 {day} 文章创建时间中的年、月和日。
+	// 以 / 结尾的，不会再添加后缀，比如 /{year}/{month}/{slug}/
+	Post string `yaml:"post"`
+
+	Index    string `yaml:"index"`    // 文章列表页，默认为 /index
+	Tags     string `yaml:"tags"`     // 标签列表页，同时也是标签详细页的前缀，默认为 /tags
+	Links    string `yaml:"links"`    // 友情链接页，默认为 /links
+	Archives string `yaml:"archives"` // 归档页，同时也是归档详细页的前缀，默认为 /archives
+	Search   string `yaml:"search"`   // 搜索页，默认为 /search
+	Themes   string `yaml:"themes"`   // 主题文件的前缀，默认为 /themes
+	Assets   string `yaml:"assets"`   // 文章资源文件的前缀，默认为 /posts
+}
+
+// 文章地址格式中可用的占位符，同时也是路由参数的名称
 const (
-	urlSuffix = ".html" // 地址后缀
-
-	urlRoot     = "/"                              // 根地址       /
-	indexURL    = urlRoot + "index" + urlSuffix    // 列表页       /index.html
-	postURL     = urlRoot + "posts"                // 文章详细页   /posts
-	tagsURL     = urlRoot + "tags" + urlSuffix     // 标签列表页   /tags.html
-	tagURL      = urlRoot + "tags"                 // 标签详细页   /tags
-	linksURL    = urlRoot + "links" + urlSuffix    // 友情链接     /links.html
-	archivesURL = urlRoot + "archives" + urlSuffix // 归档         /archives.html
-	searchURL   = urlRoot + "search" + urlSuffix   // 搜索         /search.html
-	themeURL    = urlRoot + "themes/"              // 主题目录前缀 /themes/
-	assetURL    = urlRoot + "posts/"               // 文章资源前缀 /posts/
+	URLPlaceholderSlug  = "{slug}"
+	URLPlaceholderYear  = "{year}"
+	URLPlaceholderMonth = "{month}"
+	URLPlaceholderDay   = "{day}"
 )
 
+const urlRoot = "/" // 根地址
+
+// NewURLs 返回默认的地址配置
+func NewURLs() *URLs {
+	return &URLs{
+		Base:     "",
+		Suffix:   ".html",
+		Post:     "/posts/" + URLPlaceholderSlug, // /posts/{slug}.html
+		Index:    "/index",                       // /index.html
+		Tags:     "/tags",                        // /tags.html 和 /tags/{slug}.html
+		Links:    "/links",                       // /links.html
+		Archives: "/archives",                    // /archives.html 和 /archives/{date}.html
+		Search:   "/search",                      // /search.html
+		Themes:   "/themes",                      // /themes/...
+		Assets:   "/posts",                       // /posts/...
+	}
+}
+
+// URL 为站内的地址 path 加上 Base 前缀，path 必须以 / 开头。
+// 一般用于配置文件中指定的地址，比如 RSS 和 sitemap 的地址。
+func (u *URLs) URL(path string) string {
+	return u.Base + path
+}
+
+// Lang 返回指定语言下的地址配置，即在 Base 之后加上 /{lang} 前缀。
+// 网站默认语言之外的文章，使用此方法返回的对象生成地址。
+func (u *URLs) Lang(lang string) *URLs {
+	urls := *u
+	urls.Base = u.Base + "/" + lang
+	return &urls
+}
+
 // LinksURL 生成友情链接的 URL
-func LinksURL() string {
-	return linksURL
+func (u *URLs) LinksURL() string {
+	return u.Base + u.Links + u.Suffix
+}
+
+// PostURL 构建文章的 URL，created 为文章的创建时间。
+func (u *URLs) PostURL(slug string, created time.Time) string {
+	return u.post(strings.NewReplacer(
+		URLPlaceholderSlug, strings.Trim(slug, "/"),
+		URLPlaceholderYear, created.Format("2006"),
+		URLPlaceholderMonth, created.Format("01"),
+		URLPlaceholderDay, created.Format("02"),
+	))
+}
+
+// PostPattern 文章详细页的路由项，
+// 路由参数名称与占位符相同，即 slug、year、month 和 day。
+func (u *URLs) PostPattern() string {
+	return u.post(strings.NewReplacer())
 }
 
-// PostURL 构建文章的 URL
-func PostURL(slug string) string {
-	return path.Join(postURL, slug+urlSuffix)
+func (u *URLs) post(r *strings.Replacer) string {
+	url := u.Base + r.Replace(u.Post)
+	if strings.HasSuffix(url, "/") {
+		return url
+	}
+	return url + u.Suffix
 }
 
 // PostsURL 构建文章列表的 URL
 // 首页为返回 /
 // 其它页面返回 /index.html?page=xx
-func PostsURL(page int) string {
+func (u *URLs) PostsURL(page int) string {
 	if page <= 1 {
-		return urlRoot
+		return u.Base + urlRoot
 	}
-	return indexURL + "?" + URLQueryPage + "=" + strconv.Itoa(page)
+	return u.IndexURL(page)
 }
 
 // IndexURL 构建索引首页的 URL
 // 首页为返回 /index.html
 // 其它页面返回 /index.html?page=xx
-func IndexURL(page int) string {
-	if page <= 1 {
-		return indexURL
-	}
-	return indexURL + "?" + URLQueryPage + "=" + strconv.Itoa(page)
+func (u *URLs) IndexURL(page int) string {
+	return withPage(u.Base+u.Index+u.Suffix, page)
 }
 
 // TagURL 构建标签的 URL
-func TagURL(slug string, page int) string {
-	url := path.Join(tagURL, slug+urlSuffix)
-	if page <= 1 {
-		return url
-	}
-
-	return url + "?" + URLQueryPage + "=" + strconv.Itoa(page)
+func (u *URLs) TagURL(slug string, page int) string {
+	return withPage(u.Base+path.Join(u.Tags, slug+u.Suffix), page)
 }
 
 // TagsURL 生成标签列表的 URL
-func TagsURL() string {
-	return tagsURL
+func (u *URLs) TagsURL() string {
+	return u.Base + u.Tags + u.Suffix
 }
 
 // ArchivesURL 生成归档页面的 URL
-func ArchivesURL() string {
-	return archivesURL
+func (u *URLs) ArchivesURL() string {
+	return u.Base + u.Archives + u.Suffix
+}
+
+// ArchiveURL 构建某一时间段归档页的 URL，
+// date 为年份或是年月，比如 2017 和 2017/05。
+func (u *URLs) ArchiveURL(date string, page int) string {
+	return withPage(u.Base+path.Join(u.Archives, date+u.Suffix), page)
 }
 
 // SearchURL 构建搜索页面的 URL
-func SearchURL(q string, page int) string {
-	url := searchURL // 以下的 url+= 会改变 url 本身的值，所以不能直接使用 searchURL
+func (u *URLs) SearchURL(q string, page int) string {
+	url := u.Base + u.Search + u.Suffix
 
 	if len(q) > 0 {
 		url += "?" + URLQuerySearch + "=" + q
@@ -115,13 +186,21 @@ func SearchURL(q string, page int) string {
 }
 
 // ThemeURL 构建主题文件 URL
-func ThemeURL(path string) string {
-	return static(themeURL, path)
+func (u *URLs) ThemeURL(path string) string {
+	return static(u.Base+u.Themes+"/", path)
 }
 
 // AssetURL 构建一条用于指向资源的 URL
-func AssetURL(path string) string {
-	return static(assetURL, path)
+func (u *URLs) AssetURL(path string) string {
+	return static(u.Base+u.Assets+"/", path)
+}
+
+// 为 url 加上页码的查询参数，第一页不需要。
+func withPage(url string, page int) string {
+	if page <= 1 {
+		return url
+	}
+	return url + "?" + URLQueryPage + "=" + strconv.Itoa(page)
 }
 
 func static(prefix, path string) string {
@@ -6,57 +6,129 @@ package vars
 
 import (
 	"testing"
+	"time"
 
 	"github.com/issue9/assert"
 )
 
-func TestPostURL(t *testing.T) {
+func TestURLs_PostURL(t *testing.T) {
 	a := assert.New(t)
+	u := NewURLs()
+	created := time.Date(2017, 5, 6, 0, 0, 0, 0, time.UTC)
 
-	a.Equal(PostURL("1"), "/posts/1.html")
+	a.Equal(u.PostURL("1", created), "/posts/1.html")
+	a.Equal(u.PostURL("2017/1", created), "/posts/2017/1.html")
+	a.Equal(u.PostPattern(), "/posts/{slug}.html")
+
+	u.Suffix = ""
+	a.Equal(u.PostURL("1", created), "/posts/1")
+
+	u.Post = "/{year}/{month}/{day}/{slug}/"
+	a.Equal(u.PostURL("1", created), "/2017/05/06/1/")
+	a.Equal(u.PostPattern(), "/{year}/{month}/{day}/{slug}/")
+}
+
+func TestURLs_Base(t *testing.T) {
+	a := assert.New(t)
+	u := NewURLs()
+	u.Base = "/blog"
+	created := time.Date(2017, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	a.Equal(u.URL("/rss.xml"), "/blog/rss.xml")
+	a.Equal(u.PostURL("1", created), "/blog/posts/1.html")
+	a.Equal(u.PostPattern(), "/blog/posts/{slug}.html")
+	a.Equal(u.PostsURL(1), "/blog/")
+	a.Equal(u.PostsURL(2), "/blog/index.html?"+URLQueryPage+"=2")
+	a.Equal(u.TagURL("1", 1), "/blog/tags/1.html")
+	a.Equal(u.TagsURL(), "/blog/tags.html")
+	a.Equal(u.LinksURL(), "/blog/links.html")
+	a.Equal(u.ArchivesURL(), "/blog/archives.html")
+	a.Equal(u.ArchiveURL("2017", 1), "/blog/archives/2017.html")
+	a.Equal(u.SearchURL("q", 1), "/blog/search.html?"+URLQuerySearch+"=q")
+	a.Equal(u.ThemeURL("style.css"), "/blog/themes/style.css")
+	a.Equal(u.AssetURL("1.png"), "/blog/posts/1.png")
+}
+
+func TestURLs_Lang(t *testing.T) {
+	a := assert.New(t)
+	u := NewURLs()
+	created := time.Date(2017, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	en := u.Lang("en")
+	a.Equal(en.PostURL("1", created), "/en/posts/1.html")
+	a.Equal(en.PostPattern(), "/en/posts/{slug}.html")
+	a.Equal(u.PostURL("1", created), "/posts/1.html") // 不影响原对象
+
+	u.Base = "/blog"
+	a.Equal(u.Lang("en").PostURL("1", created), "/blog/en/posts/1.html")
 }
 
-func TestPostsURL(t *testing.T) {
+func TestURLs_PostsURL(t *testing.T) {
 	a := assert.New(t)
+	u := NewURLs()
+
+	a.Equal(u.PostsURL(0), "/")
+	a.Equal(u.PostsURL(1), "/")
+	a.Equal(u.PostsURL(2), "/index.html?"+URLQueryPage+"=2")
 
-	a.Equal(PostsURL(0), "/")
-	a.Equal(PostsURL(1), "/")
-	a.Equal(PostsURL(2), "/index.html?"+URLQueryPage+"=2")
+	a.Equal(u.IndexURL(0), "/index.html")
+	a.Equal(u.IndexURL(2), "/index.html?"+URLQueryPage+"=2")
 }
 
-func TestTagURL(t *testing.T) {
+func TestURLs_TagURL(t *testing.T) {
 	a := assert.New(t)
-	a.Equal(TagURL("1", 0), "/tags/1.html")
-	a.Equal(TagURL("1", 1), "/tags/1.html")
-	a.Equal(TagURL("1", 2), "/tags/1.html?"+URLQueryPage+"=2")
+	u := NewURLs()
+
+	a.Equal(u.TagURL("1", 0), "/tags/1.html")
+	a.Equal(u.TagURL("1", 1), "/tags/1.html")
+	a.Equal(u.TagURL("1", 2), "/tags/1.html?"+URLQueryPage+"=2")
+	a.Equal(u.TagsURL(), "/tags.html")
+
+	u.Suffix = ""
+	u.Tags = "/category"
+	a.Equal(u.TagURL("1", 0), "/category/1")
+	a.Equal(u.TagsURL(), "/category")
+}
+
+func TestURLs_ArchiveURL(t *testing.T) {
+	a := assert.New(t)
+	u := NewURLs()
+
+	a.Equal(u.ArchiveURL("2017", 0), "/archives/2017.html")
+	a.Equal(u.ArchiveURL("2017/05", 1), "/archives/2017/05.html")
+	a.Equal(u.ArchiveURL("2017", 2), "/archives/2017.html?"+URLQueryPage+"=2")
+	a.Equal(u.ArchivesURL(), "/archives.html")
 }
 
-func TestSearchURL(t *testing.T) {
+func TestURLs_SearchURL(t *testing.T) {
 	a := assert.New(t)
+	u := NewURLs()
 
-	a.Equal(SearchURL("", 0), "/search.html")
-	a.Equal(SearchURL("", 1), "/search.html")
-	a.Equal(SearchURL("", 2), "/search.html?"+URLQueryPage+"=2")
+	a.Equal(u.SearchURL("", 0), "/search.html")
+	a.Equal(u.SearchURL("", 1), "/search.html")
+	a.Equal(u.SearchURL("", 2), "/search.html?"+URLQueryPage+"=2")
 
-	a.Equal(SearchURL("q", 0), "/search.html?"+URLQuerySearch+"=q")
-	a.Equal(SearchURL("q", 1), "/search.html?"+URLQuerySearch+"=q")
-	a.Equal(SearchURL("q", 2), "/search.html?q=q&amp;"+URLQueryPage+"=2")
+	a.Equal(u.SearchURL("q", 0), "/search.html?"+URLQuerySearch+"=q")
+	a.Equal(u.SearchURL("q", 1), "/search.html?"+URLQuerySearch+"=q")
+	a.Equal(u.SearchURL("q", 2), "/search.html?q=q&amp;"+URLQueryPage+"=2")
 }
 
-func TestThemesURL(t *testing.T) {
+func TestURLs_ThemeURL(t *testing.T) {
 	a := assert.New(t)
+	u := NewURLs()
 
-	a.Equal(ThemeURL(""), "/themes/")
-	a.Equal(ThemeURL("/"), "/themes/")
-	a.Equal(ThemeURL("/path"), "/themes/path")
-	a.Equal(ThemeURL("/path/1"), "/themes/path/1")
+	a.Equal(u.ThemeURL(""), "/themes/")
+	a.Equal(u.ThemeURL("/"), "/themes/")
+	a.Equal(u.ThemeURL("/path"), "/themes/path")
+	a.Equal(u.ThemeURL("/path/1"), "/themes/path/1")
 }
 
-func TestAssetURL(t *testing.T) {
+func TestURLs_AssetURL(t *testing.T) {
 	a := assert.New(t)
+	u := NewURLs()
 
-	a.Equal(AssetURL("/"), "/posts/")
-	a.Equal(AssetURL(""), "/posts/")
-	a.Equal(AssetURL("/abc.png"), "/posts/abc.png")
-	a.Equal(AssetURL("abc.png"), "/posts/abc.png")
+	a.Equal(u.AssetURL("/"), "/posts/")
+	a.Equal(u.AssetURL(""), "/posts/")
+	a.Equal(u.AssetURL("/abc.png"), "/posts/abc.png")
+	a.Equal(u.AssetURL("abc.png"), "/posts/abc.png")
 }
@@ -4,8 +4,7 @@
 
 // Package vars 代码级别的配置内容。
 //
-// 所有可能需要修改的配置项以及算法都被集中到 vars 包中，
-// 使用者可以根据自己需求随意修改此包以子包的内容。
+// 所有可能需要修改的配置项以及算法都被集中到 vars 包中。
 package vars
 
 import (
@@ -49,17 +48,22 @@ const (
 	ThemesDir = "themes"
 	MetaDir   = "meta"
 	RawsDir   = "raws"
+
+	ThemeLocalesDir = "locales" // 主题中存放各语言界面文字的目录
 )
 
 // 文件名的定义
 const (
-	AppConfigFilename  = "app.yaml"
-	LogsConfigFilename = "logs.xml"
+	AppConfigFilename   = "app.yaml"
+	LogsConfigFilename  = "logs.xml"
+	MaintenanceFilename = "maintenance.html" // 数据未加载成功时显示的页面，可选
 
 	ConfigFilename = "config.yaml"
 	TagsFilename   = "tags.yaml"
 	LinksFilename  = "links.yaml"
 
+	RedirectsFilename = "redirects.yaml"
+
 	PostMetaFilename    = "meta.yaml"
 	PostContentFilename = "content.html"
 
@@ -74,6 +78,7 @@ const (
 	PageTags     = "tags"
 	PageTag      = "tag"
 	PageArchives = "archives"
+	PageArchive  = "archive" // 某一时间段的归档页
 	PageLinks    = "links"
 	PageSearch   = "search"
 )
@@ -21,8 +21,9 @@ func TestTemplateExtension(t *testing.T) {
 func TestURLSuffix(t *testing.T) {
 	a := assert.New(t)
 
-	a.Equal(urlSuffix[0], '.')
-	a.True(len(urlSuffix) > 2)
+	suffix := NewURLs().Suffix
+	a.Equal(suffix[0], '.')
+	a.True(len(suffix) > 2)
 }
 
 func TestURL(t *testing.T) {